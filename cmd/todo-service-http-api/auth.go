@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/config"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	postgresService "github.com/RanbirSingh-Velotio/todo-service/store/postgres"
+	sqliteService "github.com/RanbirSingh-Velotio/todo-service/store/sqlite"
+	"log/slog"
+	"strings"
+)
+
+// newAuthenticator returns the authenticator configured by the [Auth] and
+// [APIKey] sections, or nil when authentication is disabled
+func newAuthenticator(st store.StoreSvc) *auth.Authenticator {
+	authConf := mainConfig().Auth
+	if authConf.Disabled {
+		slog.Warn("Authentication is disabled, every request is served")
+		return nil
+	}
+
+	staticKeys := auth.StaticKeys{}
+	for _, key := range mainConfig().APIKey {
+		staticKeys[strings.ToLower(key.Hash)] = auth.Principal{Subject: key.Subject, Roles: auth.ParseRoles(key.Roles)}
+	}
+	keys := []auth.KeyStore{staticKeys}
+	if authConf.APIKeyTable {
+		tableKeys, ok := st.(auth.KeyStore)
+		if !ok {
+			panic("the store has no api_keys table")
+		}
+		keys = append(keys, tableKeys)
+	}
+
+	var verifier *auth.JWTVerifier
+	if authConf.HMACSecret != "" || authConf.JWKSFile != "" {
+		verifier = newJWTVerifier(authConf)
+	}
+	return auth.NewAuthenticator(verifier, keys...)
+}
+
+// newJWTVerifier returns the verifier of the bearer tokens signed with the
+// configured secret or the keys of the JWKS file
+func newJWTVerifier(authConf config.AuthStruct) *auth.JWTVerifier {
+	options := auth.JWTOptions{
+		Issuer:     authConf.Issuer,
+		Audience:   authConf.Audience,
+		HMACSecret: []byte(authConf.HMACSecret),
+		Leeway:     authConf.Leeway.Duration,
+	}
+	if authConf.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(authConf.JWKSFile)
+		if err != nil {
+			panic("failed to load JWKS: " + err.Error())
+		}
+		options.Keys = keys
+		slog.Info("Loaded JWT signing keys", "file", authConf.JWKSFile, "keys", len(keys))
+	}
+	verifier, err := auth.NewJWTVerifier(options)
+	if err != nil {
+		panic("failed to set up JWT verification: " + err.Error())
+	}
+	return verifier
+}
+
+// runAPIKeyCommand handles `apikey create <subject> [roles]` and
+// `apikey revoke <subject>` on the api_keys table
+func runAPIKeyCommand(args []string) error {
+	dbConf := databaseConfig()
+	if dbConf.Driver == config.DriverMemory {
+		return fmt.Errorf("the %s store has no api_keys table", dbConf.Driver)
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("usage: apikey create <subject> [roles] | apikey revoke <subject>")
+	}
+	db := initDatabase(dbConf)
+	defer db.Close()
+	st := sqliteService.New(db).Store
+	if dbConf.Driver == config.DriverPostgres {
+		st = postgresService.New(db).Store
+	}
+	ctx := context.Background()
+
+	command, subject := args[0], args[1]
+	switch command {
+	case "create":
+		var roles []string
+		if len(args) > 2 {
+			roles = auth.ParseRoles(args[2])
+		}
+		key, err := auth.NewAPIKey()
+		if err != nil {
+			return err
+		}
+		if err := st.CreateAPIKey(ctx, auth.HashAPIKey(key), subject, roles); err != nil {
+			return err
+		}
+		// The key is shown once, only its hash is stored
+		fmt.Println(key)
+	case "revoke":
+		revoked, err := st.RevokeAPIKeys(ctx, subject)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked %d keys of %s\n", revoked, subject)
+	default:
+		return fmt.Errorf("unknown apikey command %q, expected create or revoke", command)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/config"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/health"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/RanbirSingh-Velotio/todo-service/store/migrate"
+	"github.com/RanbirSingh-Velotio/todo-service/utils/handlerutil"
+	"github.com/jmoiron/sqlx"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// minFreeDiskBytes is the free space the SQLite database directory needs to stay ready
+const minFreeDiskBytes = 64 << 20
+
+// registerHealthProbes serves /healthz, answering as long as the process
+// does, and /readyz, failing while the service cannot serve requests.
+// /health is kept for existing probes.
+func registerHealthProbes(st store.StoreSvc, db *sqlx.DB) {
+	liveness := health.NewProbe()
+	http.Handle("/healthz", liveness)
+	http.Handle("/health", liveness)
+
+	dbConf := databaseConfig()
+	checks := []health.Check{
+		{Name: "database", Critical: true, Run: func(ctx context.Context) (interface{}, error) {
+			p, ok := st.(pinger)
+			if !ok {
+				return map[string]string{"driver": dbConf.Driver}, nil
+			}
+			return map[string]string{"driver": dbConf.Driver}, p.Ping(ctx)
+		}},
+		{Name: "handlers", Critical: true, Run: checkHandlers},
+	}
+	if db != nil {
+		migrator := newMigrator(db, dbConf.Driver)
+		checks = append(checks, health.Check{Name: "migrations", Critical: true, Run: func(ctx context.Context) (interface{}, error) {
+			// CurrentVersion only reads, probes must not create tables or take write locks
+			version, err := migrator.CurrentVersion(ctx)
+			detail := map[string]int{"version": version, "latest": migrator.Latest()}
+			if errors.Is(err, migrate.ErrNotMigrated) {
+				return detail, errors.New("schema is not migrated")
+			}
+			if err != nil {
+				return nil, err
+			}
+			if version != migrator.Latest() {
+				return detail, fmt.Errorf("schema is at version %d, want %d", version, migrator.Latest())
+			}
+			return detail, nil
+		}})
+	}
+	if dbConf.Driver == config.DriverSQLite && dbConf.Path != "" {
+		dir, _ := filepath.Abs(filepath.Dir(dbConf.Path))
+		checks = append(checks, health.DiskSpace("disk", dir, minFreeDiskBytes, true))
+	}
+	http.Handle("/readyz", health.NewProbe(checks...))
+}
+
+// checkHandlers fails unless every handlerutil handler is running and healthy
+func checkHandlers(ctx context.Context) (interface{}, error) {
+	statuses := handlerutil.GetStatus()
+	var failing []string
+	for _, status := range statuses {
+		if status.State != handlerutil.StateRunning || !status.Healthy {
+			failing = append(failing, status.Identity)
+		}
+	}
+	if len(failing) > 0 {
+		return statuses, errors.New("handlers not ready: " + strings.Join(failing, ", "))
+	}
+	return statuses, nil
+}
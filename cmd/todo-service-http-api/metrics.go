@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/metrics"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// registerMetrics serves the Prometheus metrics on /metrics. It returns the
+// metrics of the todo handler and st with its operations recorded.
+func registerMetrics(st store.StoreSvc, db *sqlx.DB) (*metrics.HTTPMetrics, store.StoreSvc) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.NewTodosCollector(st),
+	)
+	if db != nil {
+		// Connection pool stats, exported as go_sql_* metrics
+		reg.MustRegister(collectors.NewDBStatsCollector(db.DB, databaseConfig().Driver))
+	}
+	http.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	return metrics.NewHTTPMetrics(reg), metrics.InstrumentStore(st, reg)
+}
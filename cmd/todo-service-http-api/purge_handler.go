@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/config"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"log/slog"
+	"time"
+)
+
+// purgeHandler removes the tasks kept in the trash longer than the
+// configured retention, every purge interval
+type purgeHandler struct {
+	config  config.TrashStruct
+	service todo.Service
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// GetIdentity returns handler identity
+func (h *purgeHandler) GetIdentity() string {
+	return "purge"
+}
+
+// Start runs the purge job in the background until the handler is stopped
+func (h *purgeHandler) Start() error {
+	if h.config.KeepForever {
+		slog.Info("Trash purge disabled, deleted tasks are kept until restored")
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	go h.run(ctx)
+	slog.Info("Trash purge started", "retention", h.config.Retention.Duration.String(), "interval", h.config.PurgeInterval.Duration.String())
+	return nil
+}
+
+func (h *purgeHandler) run(ctx context.Context) {
+	defer close(h.done)
+	ticker := time.NewTicker(h.config.PurgeInterval.Duration)
+	defer ticker.Stop()
+	for {
+		h.purge(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *purgeHandler) purge(ctx context.Context) {
+	if _, err := h.service.TodoPurgeRequest(ctx, time.Now().Add(-h.config.Retention.Duration)); err != nil && ctx.Err() == nil {
+		slog.Error("purging the trash failed", "error", err)
+	}
+}
+
+// Stop cancels a running purge and waits for the job to return
+func (h *purgeHandler) Stop(ctx context.Context) error {
+	if h.cancel == nil {
+		return nil
+	}
+	h.cancel()
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Health always succeeds, a failed purge is retried on the next interval
+func (h *purgeHandler) Health() error {
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"io"
+	"time"
+)
+
+// storeHandler registers the store with handlerutil, so it is stopped,
+// and closed, only after every handler depending on it
+type storeHandler struct {
+	store store.StoreSvc
+}
+
+// pinger is implemented by stores backed by a database connection
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// GetIdentity returns handler identity
+func (h *storeHandler) GetIdentity() string {
+	return "store"
+}
+
+// Start does nothing, the store is opened and migrated before handlers start
+func (h *storeHandler) Start() error {
+	return nil
+}
+
+// Stop closes the store
+func (h *storeHandler) Stop(ctx context.Context) error {
+	if closer, ok := h.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Health pings the database of stores that have one
+func (h *storeHandler) Health() error {
+	p, ok := h.store.(pinger)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return p.Ping(ctx)
+}
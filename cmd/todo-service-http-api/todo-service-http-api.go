@@ -1,23 +1,33 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/config"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
 	todoHandler "github.com/RanbirSingh-Velotio/todo-service/pkg/todo/handler"
 	todoService "github.com/RanbirSingh-Velotio/todo-service/pkg/todo/service"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	memoryService "github.com/RanbirSingh-Velotio/todo-service/store/memory"
+	"github.com/RanbirSingh-Velotio/todo-service/store/migrate"
+	postgresService "github.com/RanbirSingh-Velotio/todo-service/store/postgres"
 	sqliteService "github.com/RanbirSingh-Velotio/todo-service/store/sqlite"
 	"github.com/RanbirSingh-Velotio/todo-service/utils/handlerutil"
 	"github.com/google/gops/agent"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // getConfigDir returns config path(string) based on environment
@@ -25,29 +35,50 @@ func getConfigDir(environ string) string {
 
 	dir, err := filepath.Abs(filepath.Dir(os.Args[0]))
 	if err != nil {
-		log.Println(err)
+		slog.Error("cannot locate the binary", "error", err)
 		return ""
 	}
 
 	mainIniFile := "todo-main.ini"
-	baseDir := strings.TrimSuffix(dir, "cmd\\todo-service-http-api")
-	filesDir := "files\\etc\\"
-	configDir := baseDir + filesDir + fmt.Sprintf("config\\%s\\", environ)
-	if _, err := os.Stat(configDir + mainIniFile); os.IsNotExist(err) {
-		// default config files located based on bin files path,
-		// if not exists then the environment is not development or customized
-		filesDir = "\\etc\\"
-		configDir = filesDir + fmt.Sprintf("config\\%s\\", environ)
+	baseDir := strings.TrimSuffix(dir, filepath.Join("cmd", "todo-service-http-api"))
+	candidates := []string{
+		// default config files located based on bin files path
+		filepath.Join(baseDir, "files", "etc", "config", environ),
+		// binaries run from the repository root, e.g. with go run
+		filepath.Join("files", "etc", "config", environ),
 	}
-	return configDir
+	for _, configDir := range candidates {
+		if _, err := os.Stat(filepath.Join(configDir, mainIniFile)); err == nil {
+			return configDir
+		}
+	}
+	// if not exists then the environment is not development or customized
+	return filepath.Join(string(filepath.Separator), "etc", "config", environ)
 }
 
+// loadMainConfigFile loads and validates the config, exiting when it is unusable
 func loadMainConfigFile(configDir string) {
 
-	err := config.NewMainConfig(configDir + "todo-main.ini")
+	err := config.NewMainConfig(filepath.Join(configDir, "todo-main.ini"))
+	if err != nil {
+		slog.Error("error loading config", "error", err)
+	}
+	mConf, err := config.GetConfig()
 	if err != nil {
-		log.Print("error")
+		fatal("no usable config", "dir", configDir, "error", err)
+	}
+	if err := mConf.Validate(); err != nil {
+		fatal("config is not valid", "error", err)
 	}
+	if err := logging.Init(os.Stderr, mConf.Log.Format, mConf.Log.Level); err != nil {
+		fatal("cannot set up logging", "error", err)
+	}
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 func initializeConfig() {
@@ -55,43 +86,73 @@ func initializeConfig() {
 	flag.Parse()
 
 	environ := os.Getenv("APP_ENV")
-	log.Println("Environment is : " + environ)
 	if environ == "" {
 		environ = "development"
 	}
+	slog.Info("loading config", "environment", environ)
 
 	configDir := getConfigDir(environ)
 	loadMainConfigFile(configDir)
 
 	//Exit if test-flag is given
 	if *configTest {
-		log.Println("Test flag is given for config test")
+		slog.Info("Config is valid")
 		os.Exit(0)
 	}
 }
 
-// startServer start server using grace
-func startServer() {
+// startServer serves HTTP until ctx is done and returns the server, whose
+// in-flight requests are drained by shutdown. It returns nil when the server
+// stopped on its own.
+func startServer(ctx context.Context) *http.Server {
 	// gops for profiling
 	if err := agent.Listen(agent.Options{}); err != nil {
-		log.Printf("Error")
+		slog.Warn("gops agent not started", "error", err)
 	}
 
 	defer agent.Close()
 
 	// Start server
-	mConf, _ := config.GetConfig()
-	serverPort := ":" + strconv.Itoa(mConf.Server.Port)
+	serverConf := mainConfig().Server
+	server := &http.Server{
+		Addr:         ":" + strconv.Itoa(serverConf.Port),
+		ReadTimeout:  serverConf.ReadTimeout.Duration,
+		WriteTimeout: serverConf.WriteTimeout.Duration,
+		IdleTimeout:  serverConf.IdleTimeout.Duration,
+		ErrorLog:     slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
 
-	err := http.ListenAndServe(serverPort, nil)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.ListenAndServe()
+	}()
 
-	if err != nil {
-		log.Printf("unable to shutdown http server gracefully: %v\n", err)
+	select {
+	case err := <-errChan:
+		slog.Error("http server stopped", "error", err)
+		return nil
+	case <-ctx.Done():
 	}
+	return server
 }
 
-func handlerHealthCheck(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, "Success")
+// shutdown stops accepting connections, lets in-flight requests drain and
+// stops the handlers, the store last as the others depend on it. Both steps
+// share one deadline, the shutdown timeout.
+func shutdown(server *http.Server) {
+	timeout := mainConfig().Server.ShutdownTimeout.Duration
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if server != nil {
+		slog.Info("Shutting down, draining requests", "timeout", timeout.String())
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("unable to shutdown http server gracefully", "error", err)
+		}
+	}
+	if err := handlerutil.Stop(ctx); err != nil {
+		slog.Error("error stopping handlers", "error", err)
+	}
+	slog.Info("Shutdown complete")
 }
 
 func notFoundHandler(w http.ResponseWriter, r *http.Request) {
@@ -99,45 +160,192 @@ func notFoundHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func initializeTodoService() {
-	db := initDatabase()
-	sqlitSrv := sqliteService.New(db)
-	todoSrv := todoService.New(sqlitSrv)
+	st, db := initStore()
+	registerHealthProbes(st, db)
+	handlerutil.Add(&storeHandler{store: st})
+	handlerutil.Add(&tracingHandler{config: mainConfig().Tracing, env: mainConfig().Server.Env})
+	httpMetrics, instrumented := registerMetrics(st, db)
+	todoSrv := tracing.InstrumentService(todoService.New(tracing.InstrumentStore(instrumented)))
 	todo.Init(todoSrv)
-	handler := todoHandler.InitHandler(todoSrv)
-	handlerutil.Add(handler)
+	handler := todoHandler.InitHandler(todoSrv, todoHandler.Options{
+		RequestTimeout: mainConfig().Server.RequestTimeout.Duration,
+		Metrics:        httpMetrics,
+		RequireIfMatch: strings.EqualFold(mainConfig().Server.IfMatch, config.IfMatchRequired),
+		MaxBodyBytes:   mainConfig().Server.MaxBodyBytes,
+		Authenticator:  newAuthenticator(st),
+	})
+	handlerutil.Add(handler, "store", "tracing")
+	handlerutil.Add(&purgeHandler{config: mainConfig().Trash, service: todoSrv}, "store", "tracing")
 }
 
-// Database Connect function
-func initDatabase() *sqlx.DB {
-	var err error
-	db, err := sqlx.Connect("sqlite3", "todo.db")
+// mainConfig returns the config loaded by initializeConfig
+func mainConfig() *config.MainConfig {
+	mConf, err := config.GetConfig()
+	if err != nil {
+		panic("config is not loaded: " + err.Error())
+	}
+	return mConf
+}
+
+// databaseConfig returns the [Database] section of the config
+func databaseConfig() config.DatabaseStruct {
+	return mainConfig().Database
+}
+
+// initStore returns the store backend selected by the [Database] section
+// along with its database, which is nil for the in-memory store
+func initStore() (store.StoreSvc, *sqlx.DB) {
+	dbConf := databaseConfig()
+	switch dbConf.Driver {
+	case config.DriverMemory:
+		slog.Warn("Using the in-memory store, tasks are lost on exit")
+		return memoryService.New(), nil
+	case config.DriverPostgres:
+		db := initDatabase(dbConf)
+		return postgresService.New(db), db
+	case config.DriverSQLite:
+		db := initDatabase(dbConf)
+		return sqliteService.New(db), db
+	}
+	panic(fmt.Sprintf("unknown database driver %q", dbConf.Driver))
+}
+
+// dataSourceName returns the DSN of the configured SQL database
+func dataSourceName(dbConf config.DatabaseStruct) string {
+	if dbConf.Driver != config.DriverSQLite || dbConf.DSN != "" {
+		return dbConf.DSN
+	}
+	slog.Info("Using SQLite database", "path", dbConf.Path)
+	return sqliteService.DSN(dbConf.Path, sqliteService.Options{
+		BusyTimeout: dbConf.BusyTimeout.Duration,
+		JournalMode: dbConf.JournalMode,
+		Synchronous: dbConf.Synchronous,
+	})
+}
+
+// connectDatabase opens the configured SQL database
+func connectDatabase(dbConf config.DatabaseStruct) *sqlx.DB {
+	driverName := dbConf.Driver
+	if driverName == config.DriverSQLite {
+		driverName = sqliteService.DriverName
+	}
+	db, err := sqlx.Connect(driverName, dataSourceName(dbConf))
 	if err != nil {
-		panic("failed to connect database")
+		panic("failed to connect database: " + err.Error())
 	}
-	sqlStmt := `
-	create table IF NOT EXISTS todo (id integer not null primary key, name text,completed bool);
-	`
-	_, err = db.Exec(sqlStmt)
+	db.SetMaxOpenConns(dbConf.MaxOpenConns)
+	if dbConf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(dbConf.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(dbConf.ConnMaxLifetime.Duration)
+	return db
+}
+
+// newMigrator returns the migrator of the schema of the configured driver
+func newMigrator(db *sqlx.DB, driver string) *migrate.Migrator {
+	migrations := sqliteService.Migrations()
+	if driver == config.DriverPostgres {
+		migrations = postgresService.Migrations()
+	}
+	migrator, err := migrate.New(db, migrations)
 	if err != nil {
-		log.Printf("%q: %s\n", err, sqlStmt)
+		panic("failed to load migrations: " + err.Error())
+	}
+	return migrator
+}
 
+// Database Connect function
+func initDatabase(dbConf config.DatabaseStruct) *sqlx.DB {
+	db := connectDatabase(dbConf)
+
+	// Bring the schema up to date before serving any request
+	err := newMigrator(db, dbConf.Driver).Up(context.Background())
+	if err != nil {
+		panic("failed to migrate database: " + err.Error())
 	}
-	db.SetMaxOpenConns(10)
 
-	fmt.Println("Database successfully connected")
+	slog.Info("Database successfully connected", "driver", dbConf.Driver)
 	return db
 }
 
+// runMigrateCommand handles `migrate [up|down [steps]|status]`
+func runMigrateCommand(args []string) error {
+	dbConf := databaseConfig()
+	if dbConf.Driver == config.DriverMemory {
+		return fmt.Errorf("the %s store has no schema to migrate", dbConf.Driver)
+	}
+	db := connectDatabase(dbConf)
+	defer db.Close()
+	migrator := newMigrator(db, dbConf.Driver)
+	ctx := context.Background()
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		if err := migrator.Down(ctx, steps); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		applied := "pending"
+		if status.Applied {
+			applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, applied)
+	}
+	return nil
+}
+
 func main() {
+	// Log as JSON until the config sets the format and level
+	logging.Init(os.Stderr, logging.FormatJSON, "info")
 	initializeConfig()
 
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrateCommand(flag.Args()[1:]); err != nil {
+			fatal("migrate failed", "error", err)
+		}
+		return
+	}
+	if flag.Arg(0) == "apikey" {
+		if err := runAPIKeyCommand(flag.Args()[1:]); err != nil {
+			fatal("apikey failed", "error", err)
+		}
+		return
+	}
+
 	initializeTodoService()
 
-	handlerutil.Start()
+	if err := handlerutil.Start(); err != nil {
+		fatal("unable to start handlers", "error", err)
+	}
 
 	http.HandleFunc("/", notFoundHandler)
 
-	http.HandleFunc("/health", handlerHealthCheck)
-
-	startServer()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown(startServer(ctx))
 }
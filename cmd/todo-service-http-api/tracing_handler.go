@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/config"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
+	"log/slog"
+)
+
+// tracingHandler installs the configured span exporter when started and
+// flushes it when stopped, after the handlers producing spans
+type tracingHandler struct {
+	config   config.TracingStruct
+	env      string
+	provider *tracing.Provider
+}
+
+// GetIdentity returns handler identity
+func (h *tracingHandler) GetIdentity() string {
+	return "tracing"
+}
+
+// Start installs the tracer provider
+func (h *tracingHandler) Start() error {
+	provider, err := tracing.Init(context.Background(), tracing.Options{
+		ServiceName: h.config.ServiceName,
+		Environment: h.env,
+		Exporter:    h.config.Exporter,
+		Endpoint:    h.config.Endpoint,
+		Protocol:    h.config.Protocol,
+		Insecure:    h.config.Insecure,
+		File:        h.config.File,
+		SampleRatio: h.config.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	h.provider = provider
+	slog.Info("Tracing started", "exporter", h.config.Exporter)
+	return nil
+}
+
+// Stop exports the spans still buffered
+func (h *tracingHandler) Stop(ctx context.Context) error {
+	return h.provider.Shutdown(ctx)
+}
+
+// Health always succeeds, failing exports are retried by the exporter
+func (h *tracingHandler) Health() error {
+	return nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// KeyStore looks API keys up by their hash
+type KeyStore interface {
+	// LookupAPIKey returns the principal the key hashed to hash was issued
+	// to, or ErrUnauthenticated when there is no such key
+	LookupAPIKey(ctx context.Context, hash string) (Principal, error)
+}
+
+// HashAPIKey returns the hex SHA-256 of key, the form keys are stored in.
+// Keys are random and long, so a fast hash is enough to keep a leaked
+// config or table from revealing them.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey returns a new random API key
+func NewAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "todo_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// StaticKeys is a KeyStore of the keys listed in the config, by hash
+type StaticKeys map[string]Principal
+
+func (k StaticKeys) LookupAPIKey(ctx context.Context, hash string) (Principal, error) {
+	p, ok := k[hash]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+	}
+	return p, nil
+}
@@ -0,0 +1,127 @@
+// Package auth authenticates API requests with API keys or JWT bearer
+// tokens and carries the authenticated principal in contexts
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrUnauthenticated is returned for requests without valid credentials
+var ErrUnauthenticated = errors.New("UNAUTHENTICATED")
+
+// Authentication methods of a Principal
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// RoleAdmin is the role of principals that see the tasks of every user
+const RoleAdmin = "admin"
+
+// APIKeyHeader is the header an API key can be sent in, besides Authorization: Bearer
+const APIKeyHeader = "X-API-Key"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, the sub claim of a token or the
+	// subject an API key was issued to
+	Subject string
+	Roles   []string
+	// Method is how the caller authenticated, MethodAPIKey or MethodJWT
+	Method string
+}
+
+// HasRole reports whether p holds role
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext returns ctx carrying p, returned by FromContext
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, ok is false for
+// unauthenticated contexts
+func FromContext(ctx context.Context) (p Principal, ok bool) {
+	p, ok = ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// ParseRoles splits a comma separated list of roles
+func ParseRoles(roles string) []string {
+	var parsed []string
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			parsed = append(parsed, role)
+		}
+	}
+	return parsed
+}
+
+// Authenticator authenticates requests with the API keys of its key stores,
+// tried in order, or with JWT bearer tokens when it has a verifier
+type Authenticator struct {
+	keys []KeyStore
+	jwt  *JWTVerifier
+}
+
+// NewAuthenticator returns an Authenticator accepting the API keys of keys
+// and, unless jwt is nil, the tokens jwt verifies
+func NewAuthenticator(jwt *JWTVerifier, keys ...KeyStore) *Authenticator {
+	return &Authenticator{keys: keys, jwt: jwt}
+}
+
+// Authenticate returns the principal authenticated by the credentials of r.
+// An API key is read from X-API-Key or from Authorization: Bearer, where a
+// value shaped like a JWT is verified as a token instead.
+func (a *Authenticator) Authenticate(ctx context.Context, r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return a.authenticateKey(ctx, key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return Principal{}, fmt.Errorf("%w: send an API key in %s or a bearer token in Authorization", ErrUnauthenticated, APIKeyHeader)
+	}
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+	if !strings.EqualFold(scheme, "Bearer") || credentials == "" {
+		return Principal{}, fmt.Errorf("%w: Authorization must use the Bearer scheme", ErrUnauthenticated)
+	}
+	if strings.Count(credentials, ".") == 2 {
+		if a.jwt == nil {
+			return Principal{}, fmt.Errorf("%w: bearer tokens are not accepted", ErrUnauthenticated)
+		}
+		return a.jwt.Verify(credentials)
+	}
+	return a.authenticateKey(ctx, credentials)
+}
+
+// authenticateKey looks key up in every key store in turn
+func (a *Authenticator) authenticateKey(ctx context.Context, key string) (Principal, error) {
+	hash := HashAPIKey(key)
+	for _, keys := range a.keys {
+		p, err := keys.LookupAPIKey(ctx, hash)
+		if errors.Is(err, ErrUnauthenticated) {
+			continue
+		}
+		if err != nil {
+			return Principal{}, err
+		}
+		p.Method = MethodAPIKey
+		return p, nil
+	}
+	return Principal{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+}
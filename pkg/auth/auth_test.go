@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	verifier, err := NewJWTVerifier(JWTOptions{HMACSecret: secret})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() err = %v\n", err)
+	}
+	keys := StaticKeys{HashAPIKey("ci-key"): {Subject: "ci", Roles: []string{"admin"}}}
+	a := NewAuthenticator(verifier, keys)
+	token := signHS256(t, secret, map[string]interface{}{"sub": "alice", "roles": []string{"editor"}})
+
+	tests := []struct {
+		name        string
+		header      string
+		value       string
+		wantSubject string
+		wantMethod  string
+	}{
+		{"api key header", APIKeyHeader, "ci-key", "ci", MethodAPIKey},
+		{"api key bearer", "Authorization", "Bearer ci-key", "ci", MethodAPIKey},
+		{"jwt", "Authorization", "Bearer " + token, "alice", MethodJWT},
+		{"lowercase scheme", "Authorization", "bearer " + token, "alice", MethodJWT},
+		{"no credentials", "", "", "", ""},
+		{"unknown key", APIKeyHeader, "other", "", ""},
+		{"basic scheme", "Authorization", "Basic Y2k6a2V5", "", ""},
+		{"bad token", "Authorization", "Bearer a.b.c", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/todo", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			p, err := a.Authenticate(context.Background(), r)
+			if tt.wantSubject == "" {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("Authenticate() err = %v, want ErrUnauthenticated\n", err)
+				}
+				return
+			}
+			if err != nil || p.Subject != tt.wantSubject || p.Method != tt.wantMethod {
+				t.Errorf("Authenticate() = %+v, %v, want %v by %v\n", p, err, tt.wantSubject, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_KeyStoreError(t *testing.T) {
+	failing := keyStoreFunc(func(ctx context.Context, hash string) (Principal, error) {
+		return Principal{}, errors.New("database is closed")
+	})
+	a := NewAuthenticator(nil, StaticKeys{}, failing)
+	r := httptest.NewRequest(http.MethodGet, "/v1/todo", nil)
+	r.Header.Set(APIKeyHeader, "key")
+	if _, err := a.Authenticate(context.Background(), r); err == nil || errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Authenticate() err = %v, want the key store error\n", err)
+	}
+}
+
+type keyStoreFunc func(ctx context.Context, hash string) (Principal, error)
+
+func (f keyStoreFunc) LookupAPIKey(ctx context.Context, hash string) (Principal, error) {
+	return f(ctx, hash)
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Errorf("FromContext() ok = true, want false\n")
+	}
+	ctx := NewContext(context.Background(), Principal{Subject: "alice", Roles: ParseRoles(" admin, ,editor")})
+	p, ok := FromContext(ctx)
+	if !ok || p.Subject != "alice" || !p.HasRole("editor") || p.HasRole("viewer") || len(p.Roles) != 2 {
+		t.Errorf("FromContext() = %+v, %v, want alice with roles admin and editor\n", p, ok)
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"time"
+)
+
+// JWTOptions configures a JWTVerifier
+type JWTOptions struct {
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer   string
+	Audience string
+	// HMACSecret verifies HS256 tokens, none are accepted when it is empty
+	HMACSecret []byte
+	// Keys verify RS256 tokens by key id, none are accepted when it is empty
+	Keys map[string]*rsa.PublicKey
+	// Leeway tolerates clock skew in the exp, nbf and iat claims
+	Leeway time.Duration
+}
+
+// JWTVerifier verifies HS256 and RS256 bearer tokens
+type JWTVerifier struct {
+	options JWTOptions
+	parser  *jwt.Parser
+}
+
+// claims are the claims of a token a principal is built from
+type claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles"`
+}
+
+// NewJWTVerifier returns a verifier of the tokens signed with the secret or
+// the keys of options
+func NewJWTVerifier(options JWTOptions) (*JWTVerifier, error) {
+	var methods []string
+	if len(options.HMACSecret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(options.Keys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("a JWT verifier needs an HMAC secret or RSA keys")
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(options.Leeway),
+	}
+	if options.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(options.Issuer))
+	}
+	if options.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(options.Audience))
+	}
+	return &JWTVerifier{options: options, parser: jwt.NewParser(parserOptions...)}, nil
+}
+
+// Verify returns the principal named by the sub and roles claims of token
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	var c claims
+	if _, err := v.parser.ParseWithClaims(token, &c, v.key); err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid bearer token: %v", ErrUnauthenticated, err)
+	}
+	if c.Subject == "" {
+		return Principal{}, fmt.Errorf("%w: bearer token has no sub claim", ErrUnauthenticated)
+	}
+	return Principal{Subject: c.Subject, Roles: c.Roles, Method: MethodJWT}, nil
+}
+
+// key returns the key verifying token, chosen by its alg and kid headers
+func (v *JWTVerifier) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return v.options.HMACSecret, nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := v.options.Keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(v.options.Keys) == 1 {
+			for _, key := range v.options.Keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
+
+// jwks is a JSON Web Key Set, RFC 7517
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+// LoadJWKS reads the RSA signing keys of the JSON Web Key Set in file, by key id
+func LoadJWKS(file string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS returns the RSA signing keys of a JSON Web Key Set, by key id.
+// Keys of other types or uses are skipped.
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %v", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != jwt.SigningMethodRS256.Alg()) {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS: key %d has an invalid modulus: %v", i, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid JWKS: key %d has an invalid exponent", i)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("invalid JWKS: no RSA signing keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func signHS256(t *testing.T, secret []byte, claims map[string]interface{}) string {
+	t.Helper()
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims(claims)).SignedString(secret)
+	if err != nil {
+		t.Fatalf("SignedString() err = %v\n", err)
+	}
+	return token
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() err = %v\n", err)
+	}
+	return signed
+}
+
+// jwksOf returns a JSON Web Key Set holding the public key of key as kid
+func jwksOf(t *testing.T, key *rsa.PrivateKey, kid string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec", "crv": "P-256"},
+		{
+			"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		},
+	}})
+	if err != nil {
+		t.Fatalf("json.Marshal() err = %v\n", err)
+	}
+	return data
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v\n", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() err = %v\n", err)
+	}
+	keys, err := ParseJWKS(jwksOf(t, rsaKey, "k1"))
+	if err != nil {
+		t.Fatalf("ParseJWKS() err = %v\n", err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	v, err := NewJWTVerifier(JWTOptions{
+		Issuer: "https://auth.example.com/", Audience: "todo-service", HMACSecret: secret, Keys: keys, Leeway: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() err = %v\n", err)
+	}
+
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub": "alice", "iss": "https://auth.example.com/", "aud": "todo-service",
+			"exp": time.Now().Add(time.Hour).Unix(), "roles": []string{"admin"},
+		}
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := valid()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"hs256", signHS256(t, secret, valid()), false},
+		{"rs256", signRS256(t, rsaKey, "k1", valid()), false},
+		{"rs256 without kid", signRS256(t, rsaKey, "", valid()), false},
+		{"unknown kid", signRS256(t, rsaKey, "k2", valid()), true},
+		{"other key", signRS256(t, otherKey, "k1", valid()), true},
+		{"wrong secret", signHS256(t, []byte("another secret of thirty two bytes"), valid()), true},
+		{"expired", signHS256(t, secret, with("exp", time.Now().Add(-time.Hour).Unix())), true},
+		{"expired within leeway", signHS256(t, secret, with("exp", time.Now().Add(-time.Second).Unix())), false},
+		{"no exp", signRS256(t, rsaKey, "k1", with("exp", nil)), true},
+		{"wrong issuer", signHS256(t, secret, with("iss", "https://evil.example.com/")), true},
+		{"wrong audience", signHS256(t, secret, with("aud", "billing")), true},
+		{"no subject", signHS256(t, secret, with("sub", nil)), true},
+		{"not a token", "a.b.c", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("Verify() err = %v, want ErrUnauthenticated\n", err)
+				}
+				return
+			}
+			if err != nil || p.Subject != "alice" || !p.HasRole("admin") || p.Method != MethodJWT {
+				t.Errorf("Verify() = %+v, %v, want alice with role admin\n", p, err)
+			}
+		})
+	}
+}
+
+func TestJWTVerifier_NoneAlgorithm(t *testing.T) {
+	v, err := NewJWTVerifier(JWTOptions{HMACSecret: []byte("0123456789abcdef0123456789abcdef")})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier() err = %v\n", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString() err = %v\n", err)
+	}
+	if _, err := v.Verify(token); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Verify() err = %v, want ErrUnauthenticated\n", err)
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{"not json", `{`, true},
+		{"no rsa keys", `{"keys":[{"kty":"EC","kid":"ec"}]}`, true},
+		{"encryption key only", `{"keys":[{"kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`, true},
+		{"bad modulus", `{"keys":[{"kty":"RSA","n":"!","e":"AQAB"}]}`, true},
+		{"rsa key", `{"keys":[{"kty":"RSA","kid":"k","n":"AQAB","e":"AQAB"}]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := ParseJWKS([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseJWKS() = %v, %v, wantErr %v\n", keys, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,18 +2,169 @@ package config
 
 import (
 	"errors"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
 	"gopkg.in/gcfg.v1"
 	"strings"
+	"time"
 )
 
+// DefaultRequestTimeout bounds a request when RequestTimeout is not configured
+const DefaultRequestTimeout = 10 * time.Second
+
+// MinHMACSecretLength is the shortest HMACSecret accepted, the size of an HS256 key
+const MinHMACSecretLength = 32
+
+// DefaultMaxBodyBytes limits request bodies when MaxBodyBytes is not configured
+const DefaultMaxBodyBytes = 1 << 20
+
+// Duration is a time.Duration read from values such as "5s" or "1m30s"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText parses a duration value of the config file
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return errors.New("duration must not be negative")
+	}
+	d.Duration = duration
+	return nil
+}
+
 type ServerStruct struct {
 	Env  string
 	Port int
+	// RequestTimeout is the deadline of every API request, including the
+	// store queries it runs
+	RequestTimeout Duration
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound reading a request,
+	// writing its response and keep-alive connections waiting for the next one
+	ReadTimeout  Duration
+	WriteTimeout Duration
+	IdleTimeout  Duration
+	// ShutdownTimeout is how long in-flight requests may drain on SIGINT or SIGTERM
+	ShutdownTimeout Duration
+	// IfMatch is required (the default) when writes to a task must name the
+	// version they expect, or optional
+	IfMatch string
+	// MaxBodyBytes is the largest request body accepted, larger ones are
+	// rejected with 413
+	MaxBodyBytes int64
+}
+
+// Values of ServerStruct.IfMatch
+const (
+	IfMatchRequired = "required"
+	IfMatchOptional = "optional"
+)
+
+// Store backends selectable with DatabaseStruct.Driver
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+)
+
+type DatabaseStruct struct {
+	// Driver is one of sqlite3 (the default), postgres or memory
+	Driver string
+	// DSN is the data source name passed to the driver, required by postgres.
+	// SQLite databases are usually given by Path, a DSN there is used verbatim.
+	DSN string
+	// Path is the SQLite database file
+	Path string
+
+	// MaxOpenConns and MaxIdleConns bound the connection pool, zero idle
+	// connections keeps the database/sql default
+	MaxOpenConns int
+	MaxIdleConns int
+	// ConnMaxLifetime closes connections older than it, zero keeps them open
+	ConnMaxLifetime Duration
+
+	// BusyTimeout is how long SQLite waits for a lock before failing
+	BusyTimeout Duration
+	// JournalMode and Synchronous set the SQLite pragmas of the same name
+	JournalMode string
+	Synchronous string
+}
+
+type LogStruct struct {
+	// Level is the lowest level logged, one of debug, info (the default), warn or error
+	Level string
+	// Format is json (the default) or text
+	Format string
+}
+
+type TracingStruct struct {
+	// Exporter is where spans go: none (the default), otlp, stdout or file
+	Exporter string
+	// ServiceName identifies the service in spans, todo-service by default
+	ServiceName string
+	// Endpoint is the host:port of the OTLP collector, reached over
+	// Protocol, grpc (the default) or http, without TLS when Insecure is set
+	Endpoint string
+	Protocol string
+	Insecure bool
+	// File is the file the file exporter appends spans to
+	File string
+	// SampleRatio is the share of new traces recorded, 1 by default
+	SampleRatio float64
+}
+
+type TrashStruct struct {
+	// Retention is how long deleted tasks stay in the trash before they
+	// are purged, 720h (30 days) by default
+	Retention Duration
+	// PurgeInterval is how often the trash is purged, 1h by default
+	PurgeInterval Duration
+	// KeepForever turns purging off, deleted tasks stay in the trash until restored
+	KeepForever bool
+}
+
+type AuthStruct struct {
+	// Disabled serves every request without authentication, for local
+	// development only
+	Disabled bool
+	// APIKeyTable accepts the API keys of the api_keys table of the
+	// database, besides those of the [APIKey "name"] sections
+	APIKeyTable bool
+	// Issuer and Audience, when set, must match the iss and aud claims of
+	// JWT bearer tokens
+	Issuer   string
+	Audience string
+	// HMACSecret verifies HS256 tokens, JWKSFile is a JSON Web Key Set
+	// holding the public keys verifying RS256 tokens
+	HMACSecret string
+	JWKSFile   string
+	// Leeway tolerates clock skew in the time claims of tokens, 1m by default
+	Leeway Duration
+}
+
+// APIKeyStruct is an [APIKey "name"] section, a static API key
+type APIKeyStruct struct {
+	// Hash is the hex SHA-256 of the key, the key itself is not kept
+	Hash string
+	// Subject is the principal the key authenticates, the section name by default
+	Subject string
+	// Roles is a comma separated list of the roles of the principal
+	Roles string
 }
 
 type (
 	MainConfig struct {
-		Server ServerStruct
+		Server   ServerStruct
+		Database DatabaseStruct
+		Log      LogStruct
+		Tracing  TracingStruct
+		Trash    TrashStruct
+		Auth     AuthStruct
+		APIKey   map[string]*APIKeyStruct
 	}
 )
 
@@ -39,11 +190,102 @@ func NewMainConfig(filePath string) error {
 		if err != nil {
 			return err
 		}
+		mc.setDefaults()
 		MainCfg = &mc
 	}
 	return customErr
 }
 
+// setDefaults fills in the settings left out of the config file
+func (mc *MainConfig) setDefaults() {
+	server := &mc.Server
+	if server.RequestTimeout.Duration == 0 {
+		server.RequestTimeout.Duration = DefaultRequestTimeout
+	}
+	if server.ReadTimeout.Duration == 0 {
+		server.ReadTimeout.Duration = 15 * time.Second
+	}
+	if server.WriteTimeout.Duration == 0 {
+		// Leave time to write the timeout error once a request expires
+		server.WriteTimeout.Duration = server.RequestTimeout.Duration + 5*time.Second
+	}
+	if server.IdleTimeout.Duration == 0 {
+		server.IdleTimeout.Duration = 60 * time.Second
+	}
+	if server.ShutdownTimeout.Duration == 0 {
+		server.ShutdownTimeout.Duration = 20 * time.Second
+	}
+	if server.IfMatch == "" {
+		server.IfMatch = IfMatchRequired
+	}
+	if server.MaxBodyBytes == 0 {
+		server.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	if mc.Auth.Leeway.Duration == 0 {
+		mc.Auth.Leeway.Duration = time.Minute
+	}
+	for name, key := range mc.APIKey {
+		if key.Subject == "" {
+			key.Subject = name
+		}
+	}
+
+	db := &mc.Database
+	if db.Driver == "" {
+		db.Driver = DriverSQLite
+	}
+	if db.MaxOpenConns == 0 {
+		db.MaxOpenConns = 10
+	}
+	if db.Driver == DriverSQLite {
+		if db.Path == "" && db.DSN == "" {
+			db.Path = "todo.db"
+		}
+		if db.BusyTimeout.Duration == 0 {
+			db.BusyTimeout.Duration = 5 * time.Second
+		}
+		if db.JournalMode == "" {
+			// WAL lets readers run while a write is in progress
+			db.JournalMode = "WAL"
+		}
+		if db.Synchronous == "" {
+			db.Synchronous = "NORMAL"
+		}
+	}
+
+	if mc.Log.Level == "" {
+		mc.Log.Level = "info"
+	}
+	if mc.Log.Format == "" {
+		mc.Log.Format = logging.FormatJSON
+	}
+
+	tc := &mc.Tracing
+	if tc.Exporter == "" {
+		tc.Exporter = tracing.ExporterNone
+	}
+	if tc.ServiceName == "" {
+		tc.ServiceName = "todo-service"
+	}
+	if tc.Protocol == "" {
+		tc.Protocol = tracing.ProtocolGRPC
+	}
+	if tc.File == "" && tc.Exporter == tracing.ExporterFile {
+		tc.File = "traces.json"
+	}
+	if tc.SampleRatio == 0 {
+		tc.SampleRatio = 1
+	}
+
+	if mc.Trash.Retention.Duration == 0 {
+		mc.Trash.Retention.Duration = 30 * 24 * time.Hour
+	}
+	if mc.Trash.PurgeInterval.Duration == 0 {
+		mc.Trash.PurgeInterval.Duration = time.Hour
+	}
+}
+
 var GetConfig = func() (*MainConfig, error) {
 	if MainCfg == nil {
 		return nil, errors.New("Config is empty")
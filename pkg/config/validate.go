@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SQLite pragma values accepted for DatabaseStruct.JournalMode and Synchronous
+var (
+	JournalModes      = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	SynchronousLevels = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+func oneOf(value string, values []string) bool {
+	for _, v := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the loaded settings and reports every invalid one
+func (mc *MainConfig) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if mc.Server.Port < 1 || mc.Server.Port > 65535 {
+		add("Server.Port must be between 1 and 65535, got %d", mc.Server.Port)
+	}
+	if mc.Server.WriteTimeout.Duration <= mc.Server.RequestTimeout.Duration {
+		add("Server.WriteTimeout (%v) must be longer than RequestTimeout (%v) so timeouts can be reported",
+			mc.Server.WriteTimeout.Duration, mc.Server.RequestTimeout.Duration)
+	}
+	if !oneOf(mc.Server.IfMatch, []string{IfMatchRequired, IfMatchOptional}) {
+		add("Server.IfMatch must be %s or %s, got %q", IfMatchRequired, IfMatchOptional, mc.Server.IfMatch)
+	}
+	if mc.Server.MaxBodyBytes < 1 {
+		add("Server.MaxBodyBytes must be positive, got %d", mc.Server.MaxBodyBytes)
+	}
+
+	db := mc.Database
+	switch db.Driver {
+	case DriverSQLite:
+		if db.Path != "" && db.DSN != "" {
+			add("Database.Path and Database.DSN are mutually exclusive")
+		}
+		if !oneOf(db.JournalMode, JournalModes) {
+			add("Database.JournalMode must be one of %s, got %q", strings.Join(JournalModes, ", "), db.JournalMode)
+		}
+		if !oneOf(db.Synchronous, SynchronousLevels) {
+			add("Database.Synchronous must be one of %s, got %q", strings.Join(SynchronousLevels, ", "), db.Synchronous)
+		}
+	case DriverPostgres:
+		if db.DSN == "" {
+			add("Database.DSN is required by the %s driver", db.Driver)
+		}
+	case DriverMemory:
+	default:
+		add("Database.Driver must be one of %s, %s or %s, got %q", DriverSQLite, DriverPostgres, DriverMemory, db.Driver)
+	}
+	if db.MaxOpenConns < 0 {
+		add("Database.MaxOpenConns must not be negative, got %d", db.MaxOpenConns)
+	}
+	if db.MaxIdleConns < 0 || db.MaxIdleConns > db.MaxOpenConns {
+		add("Database.MaxIdleConns must be between 0 and MaxOpenConns (%d), got %d", db.MaxOpenConns, db.MaxIdleConns)
+	}
+
+	if _, err := logging.ParseLevel(mc.Log.Level); err != nil {
+		add("Log.Level must be one of debug, info, warn or error, got %q", mc.Log.Level)
+	}
+	if !oneOf(mc.Log.Format, []string{logging.FormatJSON, logging.FormatText}) {
+		add("Log.Format must be %s or %s, got %q", logging.FormatJSON, logging.FormatText, mc.Log.Format)
+	}
+
+	tc := mc.Tracing
+	switch tc.Exporter {
+	case tracing.ExporterOTLP:
+		if tc.Endpoint == "" {
+			add("Tracing.Endpoint is required by the otlp exporter")
+		}
+		if !oneOf(tc.Protocol, []string{tracing.ProtocolGRPC, tracing.ProtocolHTTP}) {
+			add("Tracing.Protocol must be grpc or http, got %q", tc.Protocol)
+		}
+	case tracing.ExporterFile:
+		if tc.File == "" {
+			add("Tracing.File is required by the file exporter")
+		}
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	default:
+		add("Tracing.Exporter must be one of none, otlp, stdout or file, got %q", tc.Exporter)
+	}
+	if tc.SampleRatio <= 0 || tc.SampleRatio > 1 {
+		add("Tracing.SampleRatio must be above 0 and at most 1, got %v", tc.SampleRatio)
+	}
+
+	if mc.Trash.PurgeInterval.Duration < time.Second {
+		add("Trash.PurgeInterval must be at least 1s, got %v", mc.Trash.PurgeInterval.Duration)
+	}
+
+	ac := mc.Auth
+	if !ac.Disabled && len(mc.APIKey) == 0 && !ac.APIKeyTable && ac.HMACSecret == "" && ac.JWKSFile == "" {
+		add("Auth needs an [APIKey] section, APIKeyTable, HMACSecret or JWKSFile unless Disabled is set")
+	}
+	if ac.APIKeyTable && db.Driver == DriverMemory {
+		add("Auth.APIKeyTable needs a %s or %s database", DriverSQLite, DriverPostgres)
+	}
+	if ac.HMACSecret != "" && len(ac.HMACSecret) < MinHMACSecretLength {
+		add("Auth.HMACSecret must be at least %d bytes, got %d", MinHMACSecretLength, len(ac.HMACSecret))
+	}
+	if ac.Leeway.Duration < 0 {
+		add("Auth.Leeway must not be negative, got %v", ac.Leeway.Duration)
+	}
+	names := make([]string, 0, len(mc.APIKey))
+	for name := range mc.APIKey {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if hash := mc.APIKey[name].Hash; len(hash) != 64 || strings.Trim(strings.ToLower(hash), "0123456789abcdef") != "" {
+			add("APIKey %q Hash must be the hex SHA-256 of the key, 64 characters", name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() MainConfig {
+	mc := MainConfig{Server: ServerStruct{Port: 30195}, Auth: AuthStruct{APIKeyTable: true}}
+	mc.setDefaults()
+	return mc
+}
+
+func TestMainConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(mc *MainConfig)
+		wantErr string
+	}{
+		{"defaults", func(mc *MainConfig) {}, ""},
+		{"memory", func(mc *MainConfig) { mc.Database.Driver, mc.Auth = DriverMemory, AuthStruct{Disabled: true} }, ""},
+		{"lowercase pragmas", func(mc *MainConfig) { mc.Database.JournalMode, mc.Database.Synchronous = "wal", "full" }, ""},
+		{"bad port", func(mc *MainConfig) { mc.Server.Port = 70000 }, "Server.Port"},
+		{"write timeout", func(mc *MainConfig) { mc.Server.WriteTimeout.Duration = mc.Server.RequestTimeout.Duration }, "Server.WriteTimeout"},
+		{"if-match", func(mc *MainConfig) { mc.Server.IfMatch = "sometimes" }, "Server.IfMatch"},
+		{"max body bytes", func(mc *MainConfig) { mc.Server.MaxBodyBytes = -1 }, "Server.MaxBodyBytes"},
+		{"unknown driver", func(mc *MainConfig) { mc.Database.Driver = "mysql" }, "Database.Driver"},
+		{"postgres without dsn", func(mc *MainConfig) { mc.Database.Driver = DriverPostgres }, "Database.DSN"},
+		{"path and dsn", func(mc *MainConfig) { mc.Database.DSN = "file:other.db" }, "mutually exclusive"},
+		{"journal mode", func(mc *MainConfig) { mc.Database.JournalMode = "fast" }, "Database.JournalMode"},
+		{"synchronous", func(mc *MainConfig) { mc.Database.Synchronous = "sometimes" }, "Database.Synchronous"},
+		{"idle above open", func(mc *MainConfig) { mc.Database.MaxIdleConns = 11 }, "Database.MaxIdleConns"},
+		{"uppercase log level", func(mc *MainConfig) { mc.Log.Level = "DEBUG" }, ""},
+		{"log level", func(mc *MainConfig) { mc.Log.Level = "verbose" }, "Log.Level"},
+		{"log format", func(mc *MainConfig) { mc.Log.Format = "xml" }, "Log.Format"},
+		{"otlp", func(mc *MainConfig) { mc.Tracing.Exporter, mc.Tracing.Endpoint = "otlp", "localhost:4317" }, ""},
+		{"otlp without endpoint", func(mc *MainConfig) { mc.Tracing.Exporter = "otlp" }, "Tracing.Endpoint"},
+		{"otlp protocol", func(mc *MainConfig) {
+			mc.Tracing.Exporter, mc.Tracing.Endpoint, mc.Tracing.Protocol = "otlp", "localhost:4317", "udp"
+		}, "Tracing.Protocol"},
+		{"unknown exporter", func(mc *MainConfig) { mc.Tracing.Exporter = "zipkin" }, "Tracing.Exporter"},
+		{"sample ratio", func(mc *MainConfig) { mc.Tracing.SampleRatio = 1.5 }, "Tracing.SampleRatio"},
+		{"purge interval", func(mc *MainConfig) { mc.Trash.PurgeInterval.Duration = time.Millisecond }, "Trash.PurgeInterval"},
+		{"no authentication", func(mc *MainConfig) { mc.Auth.APIKeyTable = false }, "Auth needs"},
+		{"authentication disabled", func(mc *MainConfig) { mc.Auth = AuthStruct{Disabled: true} }, ""},
+		{"api key table in memory", func(mc *MainConfig) { mc.Database.Driver = DriverMemory }, "Auth.APIKeyTable"},
+		{"short hmac secret", func(mc *MainConfig) { mc.Auth.HMACSecret = "secret" }, "Auth.HMACSecret"},
+		{"api key", func(mc *MainConfig) {
+			mc.APIKey = map[string]*APIKeyStruct{"ci": {Hash: strings.Repeat("ab", 32)}}
+		}, ""},
+		{"api key hash", func(mc *MainConfig) { mc.APIKey = map[string]*APIKeyStruct{"ci": {Hash: "secret"}} }, `APIKey "ci" Hash`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := validConfig()
+			tt.modify(&mc)
+			err := mc.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() err = %v, want nil\n", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() err = %v, want %v\n", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDuration_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"5s", 5 * time.Second, false},
+		{"1m30s", 90 * time.Second, false},
+		{"5", 0, true},
+		{"-1s", 0, true},
+	}
+	for _, tt := range tests {
+		var d Duration
+		err := d.UnmarshalText([]byte(tt.text))
+		if (err != nil) != tt.wantErr || d.Duration != tt.want {
+			t.Errorf("UnmarshalText(%q) = %v, %v, want %v\n", tt.text, d.Duration, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskUsage is the space of the file system holding a directory
+type DiskUsage struct {
+	Path         string `json:"path"`
+	FreeBytes    uint64 `json:"free_bytes"`
+	TotalBytes   uint64 `json:"total_bytes"`
+	MinFreeBytes uint64 `json:"min_free_bytes"`
+}
+
+// DiskSpace returns a check failing when less than minFree bytes are
+// available to the service on the file system holding dir
+func DiskSpace(name, dir string, minFree uint64, critical bool) Check {
+	return Check{
+		Name:     name,
+		Critical: critical,
+		Run: func(ctx context.Context) (interface{}, error) {
+			free, total, err := diskSpace(dir)
+			if err != nil {
+				return nil, err
+			}
+			usage := DiskUsage{Path: dir, FreeBytes: free, TotalBytes: total, MinFreeBytes: minFree}
+			if free < minFree {
+				return usage, fmt.Errorf("only %d bytes free, want at least %d", free, minFree)
+			}
+			return usage, nil
+		},
+	}
+}
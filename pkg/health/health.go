@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Check statuses reported in Result and Report
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// DefaultTimeout bounds every check of a probe
+const DefaultTimeout = 2 * time.Second
+
+// Check is one component check of a probe. A failing critical check fails
+// the whole probe, other failures are only reported.
+type Check struct {
+	Name     string
+	Critical bool
+	// Run returns details worth reporting along with the check's error
+	Run func(ctx context.Context) (interface{}, error)
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Critical   bool        `json:"critical"`
+	DurationMs int64       `json:"duration_ms"`
+	Detail     interface{} `json:"detail,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// Report is the body of a probe response
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Probe runs its checks concurrently whenever it is requested
+type Probe struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+func NewProbe(checks ...Check) *Probe {
+	return &Probe{
+		checks:  checks,
+		timeout: DefaultTimeout,
+	}
+}
+
+// Run runs every check and reports them in the order they were given
+func (p *Probe) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make([]Result, len(p.checks))}
+	var wg sync.WaitGroup
+	for i, check := range p.checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			report.Checks[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Critical && result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runCheck runs check, giving up once ctx is done
+func runCheck(ctx context.Context, check Check) Result {
+	result := Result{Name: check.Name, Status: StatusOK, Critical: check.Critical}
+	start := time.Now()
+	type outcome struct {
+		detail interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		detail, err := check.Run(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case o := <-done:
+		result.Detail, err = o.detail, o.err
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// ServeHTTP writes the report as JSON, with 503 when a critical check failed
+func (p *Probe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := p.Run(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	jsonResponse, _ := json.Marshal(report)
+	httputil.WriteResponse(w, jsonResponse, status,
+		httputil.NewContentTypeDecorator("application/json"),
+		httputil.NewHeaderDecorator("Cache-Control", "no-store"))
+}
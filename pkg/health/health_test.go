@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{Name: name, Critical: critical, Run: func(ctx context.Context) (interface{}, error) {
+		return nil, err
+	}}
+}
+
+func TestProbe_ServeHTTP(t *testing.T) {
+	failure := errors.New("down")
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus int
+		wantReport string
+	}{
+		{"no checks", nil, http.StatusOK, StatusOK},
+		{"all pass", []Check{check("a", true, nil), check("b", false, nil)}, http.StatusOK, StatusOK},
+		{"non critical failure", []Check{check("a", true, nil), check("b", false, failure)}, http.StatusOK, StatusOK},
+		{"critical failure", []Check{check("a", true, failure), check("b", false, nil)}, http.StatusServiceUnavailable, StatusFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			NewProbe(tt.checks...).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			result := w.Result()
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", result.StatusCode, tt.wantStatus)
+			}
+			var report Report
+			if err := json.NewDecoder(result.Body).Decode(&report); err != nil {
+				t.Fatalf("ServeHTTP() decode body err = %v\n", err)
+			}
+			if report.Status != tt.wantReport || len(report.Checks) != len(tt.checks) {
+				t.Errorf("ServeHTTP() report = %+v, want status %v\n", report, tt.wantReport)
+			}
+			for i, result := range report.Checks {
+				if result.Name != tt.checks[i].Name {
+					t.Errorf("ServeHTTP() check %d = %v, want %v\n", i, result.Name, tt.checks[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	p := NewProbe(Check{Name: "slow", Critical: true, Run: func(ctx context.Context) (interface{}, error) {
+		time.Sleep(time.Second)
+		return nil, nil
+	}})
+	p.timeout = time.Millisecond
+
+	report := p.Run(context.Background())
+	if report.Status != StatusFail || report.Checks[0].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Run() = %+v, want the slow check to time out\n", report)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := DiskSpace("disk", dir, 0, true).Run(context.Background()); err != nil {
+		t.Errorf("DiskSpace() err = %v, want nil\n", err)
+	}
+	if _, err := DiskSpace("disk", dir, 1<<62, true).Run(context.Background()); err == nil {
+		t.Errorf("DiskSpace() err = nil, want not enough space\n")
+	}
+}
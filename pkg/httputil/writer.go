@@ -34,3 +34,17 @@ func WriteResponse(w http.ResponseWriter, data []byte, status int, decorators ..
 	w.WriteHeader(status)
 	return w.Write(data)
 }
+
+// HeaderDecorator sets a single response header
+type HeaderDecorator struct {
+	key   string
+	value string
+}
+
+func (d *HeaderDecorator) Decorate(w http.ResponseWriter) {
+	w.Header().Set(d.key, d.value)
+}
+
+func NewHeaderDecorator(key, value string) *HeaderDecorator {
+	return &HeaderDecorator{key: key, value: value}
+}
@@ -132,3 +132,29 @@ func TestWriteResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestHeaderDecorator_Decorate(t *testing.T) {
+	tests := []struct {
+		name  string
+		d     *HeaderDecorator
+		key   string
+		value string
+	}{
+		{
+			"location",
+			NewHeaderDecorator("Location", "/v1/todo/1"),
+			"location",
+			"/v1/todo/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.d.Decorate(w)
+			result := w.Result()
+			if got := result.Header.Get(tt.key); got != tt.value {
+				t.Errorf("HeaderDecorator_Decorate() = %v, want %v\n", got, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	if err := Init(&buf, FormatJSON, "warn"); err != nil {
+		t.Fatalf("Init() err = %v\n", err)
+	}
+	ctx := WithRequestID(context.Background(), "abc")
+	FromContext(ctx).Info("dropped")
+	FromContext(ctx).Warn("kept", "id", 7)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Init() wrote %q, want one JSON record: %v\n", buf.String(), err)
+	}
+	if record["msg"] != "kept" || record["request_id"] != "abc" || record["id"] != float64(7) {
+		t.Errorf("Init() record = %v, want the warning with its request id\n", record)
+	}
+
+	if err := Init(&buf, "xml", "info"); err == nil {
+		t.Errorf("Init() format xml err = nil, want an error\n")
+	}
+	if err := Init(&buf, FormatText, "loud"); err == nil {
+		t.Errorf("Init() level loud err = nil, want an error\n")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	if got := RequestID(context.Background()); got != "" {
+		t.Errorf("RequestID() = %v, want none\n", got)
+	}
+	if got := RequestID(WithRequestID(context.Background(), "abc")); got != "abc" {
+		t.Errorf("RequestID() = %v, want %v\n", got, "abc")
+	}
+	if id := NewRequestID(); len(id) != 32 || !ValidRequestID(id) {
+		t.Errorf("NewRequestID() = %v, want 32 hex digits\n", id)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"req-1:a/b", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{string(make([]byte, 129)), false},
+	}
+	for _, tt := range tests {
+		if got := ValidRequestID(tt.id); got != tt.want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v\n", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/memory"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("%w: no task", todo.ErrNotFound), "not_found"},
+		{fmt.Errorf("%w: locked", todo.ErrUnavailable), "unavailable"},
+		{fmt.Errorf("%w: task is at version 2", todo.ErrPreconditionFailed), "precondition_failed"},
+		{fmt.Errorf("%w: query", context.DeadlineExceeded), "deadline_exceeded"},
+		{context.Canceled, "canceled"},
+		{errors.New("disk I/O error"), "internal"},
+	}
+	for _, tt := range tests {
+		if got := errorKind(tt.err); got != tt.want {
+			t.Errorf("errorKind(%v) = %v, want %v\n", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestInstrumentStore(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	st := InstrumentStore(memory.New(), reg)
+	ctx := context.Background()
+
+	if _, err := st.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Priority: todo.PriorityMedium}); err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	if _, err := st.GetTodoTaskByID(ctx, []int{2}); !errors.Is(err, todo.ErrNotFound) {
+		t.Fatalf("GetTodoTaskByID() err = %v, want %v\n", err, todo.ErrNotFound)
+	}
+	if _, err := st.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: 1, Name: "b", Priority: todo.PriorityMedium, Version: 7}); !errors.Is(err, todo.ErrPreconditionFailed) {
+		t.Fatalf("UpdateTodoTaskByID() err = %v, want %v\n", err, todo.ErrPreconditionFailed)
+	}
+
+	if got := testutil.CollectAndCount(reg, "todo_store_operation_duration_seconds"); got != 3 {
+		t.Errorf("store_operation_duration_seconds series = %v, want %v\n", got, 3)
+	}
+	want := `
+# HELP todo_store_operation_errors_total Number of failed store operations by method and error kind.
+# TYPE todo_store_operation_errors_total counter
+todo_store_operation_errors_total{kind="not_found",method="GetTodoTaskByID"} 1
+todo_store_operation_errors_total{kind="precondition_failed",method="UpdateTodoTaskByID"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "todo_store_operation_errors_total"); err != nil {
+		t.Errorf("store_operation_errors_total: %v\n", err)
+	}
+}
+
+func TestTodosCollector(t *testing.T) {
+	st := memory.New()
+	for _, completed := range []bool{true, false, false} {
+		if _, err := st.CreateTodoTask(context.Background(), todo.TodoRequestInput{Name: "a", Completed: completed, Priority: todo.PriorityMedium}); err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+
+	want := `
+# HELP todo_todos_total Number of stored tasks by completion state.
+# TYPE todo_todos_total gauge
+todo_todos_total{completed="false"} 2
+todo_todos_total{completed="true"} 1
+`
+	if err := testutil.CollectAndCompare(NewTodosCollector(st), strings.NewReader(want)); err != nil {
+		t.Errorf("todos_total: %v\n", err)
+	}
+}
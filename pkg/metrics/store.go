@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// storeMetrics times the store operations and counts their errors by kind
+type storeMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// instrumentedStore records the duration and errors of every call to the wrapped store
+type instrumentedStore struct {
+	store   store.StoreSvc
+	metrics storeMetrics
+}
+
+// InstrumentStore returns st with its operations recorded in metrics registered with reg
+func InstrumentStore(st store.StoreSvc, reg prometheus.Registerer) store.StoreSvc {
+	m := storeMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Time taken by store operations by method.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "store_operation_errors_total",
+			Help:      "Number of failed store operations by method and error kind.",
+		}, []string{"method", "kind"}),
+	}
+	reg.MustRegister(m.duration, m.errors)
+	return &instrumentedStore{store: st, metrics: m}
+}
+
+// errorKinds maps the errors a store returns to the kind label of the errors metric
+var errorKinds = []struct {
+	err  error
+	kind string
+}{
+	{context.DeadlineExceeded, "deadline_exceeded"},
+	{context.Canceled, "canceled"},
+	{todo.ErrNotFound, "not_found"},
+	{todo.ErrConflict, "conflict"},
+	{todo.ErrValidation, "validation"},
+	{todo.ErrUnavailable, "unavailable"},
+	{todo.ErrForbidden, "forbidden"},
+	{todo.ErrPreconditionFailed, "precondition_failed"},
+}
+
+func errorKind(err error) string {
+	for _, ek := range errorKinds {
+		if errors.Is(err, ek.err) {
+			return ek.kind
+		}
+	}
+	return "internal"
+}
+
+// observe records a call to method that started at start and returned err
+func (m storeMetrics) observe(method string, start time.Time, err error) {
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(method, errorKind(err)).Inc()
+	}
+}
+
+func (s *instrumentedStore) ListTodoTasks(ctx context.Context, query todo.TodoQuery) (response todo.TodoListResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("ListTodoTasks", start, err)
+	}(time.Now())
+	return s.store.ListTodoTasks(ctx, query)
+}
+
+func (s *instrumentedStore) CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("CreateTodoTask", start, err)
+	}(time.Now())
+	return s.store.CreateTodoTask(ctx, requestInput)
+}
+
+func (s *instrumentedStore) GetTodoTaskByID(ctx context.Context, id []int) (todos []todo.TodoResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("GetTodoTaskByID", start, err)
+	}(time.Now())
+	return s.store.GetTodoTaskByID(ctx, id)
+}
+
+func (s *instrumentedStore) DeleteTodoTaskByID(ctx context.Context, id []int, version int) (response todo.TodoDeleteResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("DeleteTodoTaskByID", start, err)
+	}(time.Now())
+	return s.store.DeleteTodoTaskByID(ctx, id, version)
+}
+
+func (s *instrumentedStore) RestoreTodoTaskByID(ctx context.Context, id int) (response todo.TodoResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("RestoreTodoTaskByID", start, err)
+	}(time.Now())
+	return s.store.RestoreTodoTaskByID(ctx, id)
+}
+
+func (s *instrumentedStore) PurgeTodoTasks(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("PurgeTodoTasks", start, err)
+	}(time.Now())
+	return s.store.PurgeTodoTasks(ctx, deletedBefore)
+}
+
+func (s *instrumentedStore) UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("UpdateTodoTaskByID", start, err)
+	}(time.Now())
+	return s.store.UpdateTodoTaskByID(ctx, requestInput)
+}
+
+func (s *instrumentedStore) SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (response todo.TodoSearchResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("SearchTodoTasks", start, err)
+	}(time.Now())
+	return s.store.SearchTodoTasks(ctx, query)
+}
+
+func (s *instrumentedStore) CountTodoTasks(ctx context.Context) (counts todo.TodoCounts, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("CountTodoTasks", start, err)
+	}(time.Now())
+	return s.store.CountTodoTasks(ctx)
+}
+
+func (s *instrumentedStore) BatchTodoTasks(ctx context.Context, request todo.TodoBatchRequest) (response todo.TodoBatchResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("BatchTodoTasks", start, err)
+	}(time.Now())
+	return s.store.BatchTodoTasks(ctx, request)
+}
+
+func (s *instrumentedStore) CreateList(ctx context.Context, input todo.ListRequestInput, owner string) (response todo.ListResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("CreateList", start, err)
+	}(time.Now())
+	return s.store.CreateList(ctx, input, owner)
+}
+
+func (s *instrumentedStore) GetList(ctx context.Context, id int) (response todo.ListResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("GetList", start, err)
+	}(time.Now())
+	return s.store.GetList(ctx, id)
+}
+
+func (s *instrumentedStore) ListLists(ctx context.Context, member string) (lists []todo.ListResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("ListLists", start, err)
+	}(time.Now())
+	return s.store.ListLists(ctx, member)
+}
+
+func (s *instrumentedStore) UpdateList(ctx context.Context, input todo.ListRequestInput) (response todo.ListResponse, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("UpdateList", start, err)
+	}(time.Now())
+	return s.store.UpdateList(ctx, input)
+}
+
+func (s *instrumentedStore) DeleteList(ctx context.Context, id int) (err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("DeleteList", start, err)
+	}(time.Now())
+	return s.store.DeleteList(ctx, id)
+}
+
+func (s *instrumentedStore) PutListMember(ctx context.Context, id int, member todo.ListMember) (err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("PutListMember", start, err)
+	}(time.Now())
+	return s.store.PutListMember(ctx, id, member)
+}
+
+func (s *instrumentedStore) DeleteListMember(ctx context.Context, id int, subject string) (err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("DeleteListMember", start, err)
+	}(time.Now())
+	return s.store.DeleteListMember(ctx, id, subject)
+}
+
+func (s *instrumentedStore) ListRoles(ctx context.Context, subject string) (roles map[int]string, err error) {
+	defer func(start time.Time) {
+		s.metrics.observe("ListRoles", start, err)
+	}(time.Now())
+	return s.store.ListRoles(ctx, subject)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// countTimeout bounds the count query run on every scrape
+const countTimeout = 5 * time.Second
+
+// todosCollector reports the number of stored tasks by completion state,
+// counted by the store when metrics are scraped
+type todosCollector struct {
+	store store.StoreSvc
+	desc  *prometheus.Desc
+}
+
+// NewTodosCollector returns the collector of the todos_total gauge of st
+func NewTodosCollector(st store.StoreSvc) prometheus.Collector {
+	return &todosCollector{
+		store: st,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "todos_total"),
+			"Number of stored tasks by completion state.",
+			[]string{"completed"}, nil,
+		),
+	}
+}
+
+func (c *todosCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *todosCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+	counts, err := c.store.CountTodoTasks(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts.Completed), "true")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts.Open), "false")
+}
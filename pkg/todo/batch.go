@@ -0,0 +1,144 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Batch modes. An atomic batch is rolled back as a whole when one operation
+// fails, a best effort batch keeps the operations that succeeded.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// Operations a batch can apply
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// MaxBatchOperations is the most operations a batch may hold
+const MaxBatchOperations = 100
+
+// ErrBatchAborted is the error of the operations of an atomic batch that
+// were rolled back, or never applied, because another operation failed
+var ErrBatchAborted = errors.New("BATCH_ABORTED")
+
+// TodoBatchOperation is one operation of a batch
+type TodoBatchOperation struct {
+	Op string `json:"op"`
+	// Todo is the task to create or, along with its id, the new fields of
+	// the task to update
+	Todo *TodoRequestInput `json:"todo,omitempty"`
+	// Ids are the tasks to delete
+	Ids []int `json:"ids,omitempty"`
+}
+
+// TodoBatchRequest is a list of operations applied in order in one transaction
+type TodoBatchRequest struct {
+	Mode       string               `json:"mode"`
+	Operations []TodoBatchOperation `json:"operations"`
+}
+
+// TodoBatchResult is the outcome of one operation of a batch
+type TodoBatchResult struct {
+	Op string `json:"op"`
+	// Status is the HTTP status the operation would have been answered
+	// with as a request of its own
+	Status  int                 `json:"status"`
+	Todo    *TodoResponse       `json:"todo,omitempty"`
+	Deleted *TodoDeleteResponse `json:"deleted,omitempty"`
+	Error   string              `json:"error,omitempty"`
+	// Err is the error the operation failed with
+	Err error `json:"-"`
+}
+
+// TodoBatchResponse holds the result of every operation of a batch, in order
+type TodoBatchResponse struct {
+	Mode string `json:"mode"`
+	// Committed is false when an atomic batch was rolled back
+	Committed bool              `json:"committed"`
+	Results   []TodoBatchResult `json:"results"`
+}
+
+// Normalize fills the default mode and normalizes the tasks of every operation
+func (r *TodoBatchRequest) Normalize() {
+	r.Mode = strings.ToLower(r.Mode)
+	if r.Mode == "" {
+		r.Mode = BatchAtomic
+	}
+	for i := range r.Operations {
+		op := &r.Operations[i]
+		op.Op = strings.ToLower(op.Op)
+		if op.Todo != nil {
+			op.Todo.Normalize()
+		}
+	}
+}
+
+// Validate checks the mode and every operation, a batch with an invalid
+// operation is rejected as a whole
+func (r TodoBatchRequest) Validate() error {
+	var v Validator
+	v.Check("mode", OneOf(r.Mode, []string{BatchAtomic, BatchBestEffort}))
+	v.Check("operations", Must(len(r.Operations) > 0, "is required"), MaxItems(len(r.Operations), MaxBatchOperations))
+	for i, op := range r.Operations {
+		v.Nest(fmt.Sprintf("operations[%d]", i), op.Validate())
+	}
+	return v.Err()
+}
+
+// Validate checks op carries what its kind of operation needs
+func (op TodoBatchOperation) Validate() error {
+	var v Validator
+	v.Check("op", OneOf(op.Op, []string{OpCreate, OpUpdate, OpDelete}))
+	switch op.Op {
+	case OpCreate, OpUpdate:
+		if op.Todo == nil {
+			v.Add("todo", "is required")
+			break
+		}
+		if op.Op == OpCreate {
+			v.Check("todo.id", Must(op.Todo.Id == 0, "is assigned by the server and must not be set"))
+		} else {
+			v.Check("todo.id", Min(op.Todo.Id, 1))
+		}
+		v.Nest("todo", op.Todo.Validate())
+	case OpDelete:
+		v.Check("ids", Must(len(op.Ids) > 0, "is required"))
+		for i, id := range op.Ids {
+			v.Check(fmt.Sprintf("ids[%d]", i), Min(id, 1))
+		}
+	}
+	return v.Err()
+}
+
+// Abort marks an atomic batch as rolled back after the operation at index
+// failed: the results of every other operation are dropped and replaced by
+// ErrBatchAborted
+func (r *TodoBatchResponse) Abort(failed int) {
+	r.Committed = false
+	for i := range r.Results {
+		if i == failed {
+			continue
+		}
+		r.Results[i] = TodoBatchResult{
+			Op:  r.Results[i].Op,
+			Err: fmt.Errorf("%w: operation %d failed", ErrBatchAborted, failed),
+		}
+	}
+}
+
+// Failed returns how many operations of the batch failed
+func (r TodoBatchResponse) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
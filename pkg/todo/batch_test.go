@@ -0,0 +1,86 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTodoBatchRequest_Validate(t *testing.T) {
+	task := &TodoRequestInput{Name: "task", Priority: PriorityLow}
+	many := make([]TodoBatchOperation, MaxBatchOperations+1)
+	for i := range many {
+		many[i] = TodoBatchOperation{Op: OpDelete, Ids: []int{1}}
+	}
+	tests := []struct {
+		name    string
+		request TodoBatchRequest
+		wantErr string
+	}{
+		{"valid", TodoBatchRequest{Mode: BatchBestEffort, Operations: []TodoBatchOperation{
+			{Op: OpCreate, Todo: task},
+			{Op: OpUpdate, Todo: &TodoRequestInput{Id: 1, Name: "task", Priority: PriorityLow}},
+			{Op: OpDelete, Ids: []int{1, 2}},
+		}}, ""},
+		{"unknown mode", TodoBatchRequest{Mode: "eventually", Operations: []TodoBatchOperation{{Op: OpDelete, Ids: []int{1}}}}, "mode must be one of"},
+		{"no operations", TodoBatchRequest{Mode: BatchAtomic}, "operations is required"},
+		{"too many operations", TodoBatchRequest{Mode: BatchAtomic, Operations: many}, "operations must hold at most"},
+		{"unknown op", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: "upsert"}}}, "operations[0].op must be one of"},
+		{"create without todo", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: OpCreate}}}, "operations[0].todo is required"},
+		{"create with id", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{
+			{Op: OpCreate, Todo: task},
+			{Op: OpCreate, Todo: &TodoRequestInput{Id: 3, Name: "task", Priority: PriorityLow}},
+		}}, "operations[1].todo.id is assigned by the server"},
+		{"update without id", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: OpUpdate, Todo: task}}}, "operations[0].todo.id must be at least 1"},
+		{"invalid task", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: OpCreate, Todo: &TodoRequestInput{Priority: PriorityLow}}}}, "operations[0].todo.name is required"},
+		{"delete without ids", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: OpDelete}}}, "operations[0].ids is required"},
+		{"delete bad id", TodoBatchRequest{Mode: BatchAtomic, Operations: []TodoBatchOperation{{Op: OpDelete, Ids: []int{0}}}}, "operations[0].ids[0] must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() err = %v, want nil\n", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() err = %v, want ErrValidation containing %q\n", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTodoBatchRequest_Normalize(t *testing.T) {
+	request := TodoBatchRequest{Operations: []TodoBatchOperation{{Op: "CREATE", Todo: &TodoRequestInput{Name: "task"}}}}
+	request.Normalize()
+	if request.Mode != BatchAtomic {
+		t.Errorf("Normalize() mode = %v, want %v\n", request.Mode, BatchAtomic)
+	}
+	if op := request.Operations[0]; op.Op != OpCreate || op.Todo.Priority != PriorityMedium {
+		t.Errorf("Normalize() operation = %+v, want a normalized create\n", op)
+	}
+}
+
+func TestTodoBatchResponse_Abort(t *testing.T) {
+	failure := errors.New("NOT_FOUND")
+	response := TodoBatchResponse{Committed: true, Results: []TodoBatchResult{
+		{Op: OpCreate, Todo: &TodoResponse{Id: 1}},
+		{Op: OpUpdate, Err: failure},
+		{Op: OpDelete},
+	}}
+	response.Abort(1)
+	if response.Committed {
+		t.Errorf("Abort() committed = true, want false\n")
+	}
+	for i, result := range response.Results {
+		wantAborted := i != 1
+		if errors.Is(result.Err, ErrBatchAborted) != wantAborted || result.Todo != nil {
+			t.Errorf("Abort() results[%d] = %+v, want aborted %v\n", i, result, wantAborted)
+		}
+	}
+	if got := response.Failed(); got != 3 {
+		t.Errorf("Failed() = %v, want 3\n", got)
+	}
+}
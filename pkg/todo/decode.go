@@ -0,0 +1,63 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DecodeJSON decodes the single JSON value held by data into v. Unknown
+// fields and values of the wrong type fail with a *ValidationError naming
+// the field, any other malformed document with a plain error.
+func DecodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("body must hold a single JSON value")
+	}
+	return nil
+}
+
+// decodeError converts the error of json.Decoder.Decode
+func decodeError(err error) error {
+	var v Validator
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		v.Add(fieldPath(typeErr.Field), fmt.Sprintf("must be of type %s, got %s", typeErr.Type, typeErr.Value))
+		return v.Err()
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// json.Decoder reports unknown fields with no error type of their own
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		v.Add(field, "is not a known field")
+		return v.Err()
+	case errors.Is(err, io.EOF):
+		return errors.New("body is empty")
+	}
+	return errors.New("invalid JSON: " + strings.TrimPrefix(err.Error(), "json: "))
+}
+
+// fieldPath spells the path of a decoded field the way Validator does, e.g.
+// operations[0].todo.name for operations.0.todo.name
+func fieldPath(path string) string {
+	parts := strings.Split(path, ".")
+	var b strings.Builder
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
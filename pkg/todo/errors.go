@@ -0,0 +1,24 @@
+package todo
+
+import (
+	"errors"
+)
+
+// Error kinds returned by Service and store implementations.
+// Callers wrap them with fmt.Errorf("%w: ...") to add detail and
+// match them with errors.Is.
+var (
+	ErrNotFound   = errors.New("NOT_FOUND")
+	ErrConflict   = errors.New("CONFLICT")
+	ErrValidation = errors.New("VALIDATION_FAILED")
+	ErrInternal   = errors.New("INTERNAL_ERROR")
+	// ErrUnavailable is returned when the store cannot serve the request right
+	// now, e.g. because the database is locked, and the request may be retried
+	ErrUnavailable = errors.New("SERVICE_UNAVAILABLE")
+	// ErrPreconditionFailed is returned when a write names a version the
+	// task is no longer at, because it was changed in the meantime
+	ErrPreconditionFailed = errors.New("PRECONDITION_FAILED")
+	// ErrForbidden is returned when the caller sees a resource but its role
+	// does not allow the change it asked for
+	ErrForbidden = errors.New("FORBIDDEN")
+)
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"net/http"
+	"strings"
+)
+
+var (
+	errBadRequest       = errors.New("BAD_REQUEST")
+	errRequestTimeOut   = errors.New("REQUEST_TIMEOUT")
+	errMethodNotAllowed = errors.New("METHOD_NOT_ALLOWED")
+	errUnsupportedMedia = errors.New("UNSUPPORTED_MEDIA_TYPE")
+	errRequestTooLarge  = errors.New("REQUEST_TOO_LARGE")
+	// errPreconditionRequired is returned for writes without If-Match when
+	// the handler requires one
+	errPreconditionRequired = errors.New("PRECONDITION_REQUIRED")
+)
+
+// errorStatuses maps every known error kind to its HTTP status code.
+// Errors that match none of them are reported as internal errors.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{errBadRequest, http.StatusBadRequest},
+	{errMethodNotAllowed, http.StatusMethodNotAllowed},
+	{errUnsupportedMedia, http.StatusUnsupportedMediaType},
+	{errRequestTooLarge, http.StatusRequestEntityTooLarge},
+	{errRequestTimeOut, http.StatusGatewayTimeout},
+	{errPreconditionRequired, http.StatusPreconditionRequired},
+	{auth.ErrUnauthenticated, http.StatusUnauthorized},
+	{todo.ErrForbidden, http.StatusForbidden},
+	{todo.ErrNotFound, http.StatusNotFound},
+	{todo.ErrConflict, http.StatusConflict},
+	{todo.ErrValidation, http.StatusUnprocessableEntity},
+	{todo.ErrUnavailable, http.StatusServiceUnavailable},
+	{todo.ErrPreconditionFailed, http.StatusPreconditionFailed},
+}
+
+// contextError converts the error of a done request context: an expired
+// deadline is reported as a timeout, a cancelled request as unavailable
+func contextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: request did not complete in time", errRequestTimeOut)
+	}
+	return fmt.Errorf("%w: request was cancelled", todo.ErrUnavailable)
+}
+
+// errorStatus returns the error kind of err and its HTTP status code
+func errorStatus(err error) (error, int) {
+	for _, es := range errorStatuses {
+		if errors.Is(err, es.err) {
+			return es.err, es.status
+		}
+	}
+	return todo.ErrInternal, http.StatusInternalServerError
+}
+
+// errorResponse writes err as a StandardError JSON body with the matching
+// status code and returns the status code
+func errorResponse(ctx context.Context, w http.ResponseWriter, err error) int {
+	kind, code := errorStatus(err)
+	detail := strings.TrimPrefix(err.Error(), kind.Error()+": ")
+	if code == http.StatusInternalServerError {
+		// Internal errors may carry driver details that clients should not see
+		logging.FromContext(ctx).Error("internal error", "error", err)
+		detail = "an unexpected error occurred"
+	} else {
+		logging.FromContext(ctx).Debug("request failed", "error", err)
+	}
+
+	text := []string{detail}
+	// Validation errors list every invalid field, one per entry
+	var validationErr *todo.ValidationError
+	if errors.As(err, &validationErr) {
+		text = validationErr.Messages()
+	}
+
+	stdErr := httputil.StandardError{
+		Code:   kind.Error(),
+		Title:  http.StatusText(code),
+		Detail: detail,
+		Object: httputil.ErrorObject{
+			Text: text,
+		},
+	}
+	decorators := []httputil.ResponseDecorator{httputil.NewContentTypeDecorator("application/json")}
+	if code == http.StatusServiceUnavailable {
+		decorators = append(decorators, httputil.NewHeaderDecorator("Retry-After", "1"))
+	}
+	jsonResponse, _ := json.Marshal(stdErr)
+	httputil.WriteResponse(w, jsonResponse, code, decorators...)
+	return code
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag returns the entity tag of a task at version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// etagDecorators set the ETag header of a response holding t
+func etagDecorators(t todo.TodoResponse) []httputil.ResponseDecorator {
+	if t.Version == 0 {
+		return nil
+	}
+	return []httputil.ResponseDecorator{httputil.NewHeaderDecorator("ETag", etag(t.Version))}
+}
+
+// parseETag returns the version named by a strong entity tag such as "3"
+func parseETag(tag string) (int, bool) {
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// ifMatchVersion returns the version the If-Match header requires the task
+// to be at, or 0 when any version will do. It fails with
+// errPreconditionRequired when the header is missing and the handler
+// requires it.
+func (h *Handler) ifMatchVersion(r *http.Request) (int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch value {
+	case "":
+		if h.options.RequireIfMatch {
+			return 0, fmt.Errorf("%w: send the ETag of the task in If-Match", errPreconditionRequired)
+		}
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+	version, ok := parseETag(value)
+	if !ok {
+		return 0, fmt.Errorf("%w: If-Match must hold a single ETag such as \"3\", got %s", errBadRequest, value)
+	}
+	return version, nil
+}
+
+// notModified reports whether the If-None-Match header of r matches the
+// ETag of a task at version, compared weakly as for every read
+func notModified(r *http.Request, version int) bool {
+	value := r.Header.Get("If-None-Match")
+	if value == "" {
+		return false
+	}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,28 +4,98 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/metrics"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
+	"log/slog"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type Handler struct {
 	service todo.Service
+	router  *router
+	options Options
+	// inflight counts the request goroutines still running, including
+	// those abandoned after their request timed out. No more are added
+	// once stopping is set.
+	mu       sync.Mutex
+	started  bool
+	stopping bool
+	inflight sync.WaitGroup
 }
 
-var (
-	errBadRequest     = errors.New("BAD_REQUEST")
-	errRequestTimeOut = errors.New("REQUEST_TIMEOUT")
-)
+// Options configures a Handler
+type Options struct {
+	// RequestTimeout is the deadline given to every request, zero disables it
+	RequestTimeout time.Duration
+	// Metrics records every request, nil disables it
+	Metrics *metrics.HTTPMetrics
+	// RequireIfMatch rejects writes to a single task that do not name the
+	// version they expect in If-Match, or in the body, with 428
+	RequireIfMatch bool
+	// MaxBodyBytes is the largest request body accepted, larger bodies are
+	// rejected with 413. Zero uses DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// Authenticator authenticates every request, nil serves them all
+	// without authentication
+	Authenticator *auth.Authenticator
+}
+
+// DefaultMaxBodyBytes is the request body limit used when Options sets none
+const DefaultMaxBodyBytes = 1 << 20
+
+// response is what a request handler produces on success
+type response struct {
+	status     int
+	body       interface{}
+	decorators []httputil.ResponseDecorator
+}
 
-func InitHandler(service todo.Service) *Handler {
-	return &Handler{
+func InitHandler(service todo.Service, options Options) *Handler {
+	h := &Handler{
 		service: service,
+		router:  &router{},
+		options: options,
 	}
+	h.routes()
+	return h
+}
+
+// routes registers every todo and list endpoint on the handler's router
+func (h *Handler) routes() {
+	// Collection endpoints, PUT and DELETE keep accepting the id in the body
+	// and the ids query parameter for backwards compatibility
+	h.router.handle(http.MethodGet, "/v1/todo", h.HandleGetRequest)
+	h.router.handle(http.MethodPost, "/v1/todo", h.HandleCreateRequest)
+	h.router.handle(http.MethodPut, "/v1/todo", h.HandlePutRequest)
+	h.router.handle(http.MethodDelete, "/v1/todo", h.HandleDeleteRequest)
+
+	h.router.handle(http.MethodGet, "/v1/todo/search", h.HandleSearchRequest)
+	h.router.handle(http.MethodGet, "/v1/todo/trash", h.HandleTrashRequest)
+	h.router.handle(http.MethodPost, "/v1/todo/batch", h.HandleBatchRequest)
+
+	h.router.handle(http.MethodGet, "/v1/todo/{id}", h.HandleGetItemRequest)
+	h.router.handle(http.MethodPut, "/v1/todo/{id}", h.HandlePutRequest)
+	h.router.handle(http.MethodPatch, "/v1/todo/{id}", h.HandlePatchRequest)
+	h.router.handle(http.MethodDelete, "/v1/todo/{id}", h.HandleDeleteRequest)
+	h.router.handle(http.MethodPost, "/v1/todo/{id}/restore", h.HandleRestoreRequest)
+
+	h.listRoutes()
 }
 
 // GetIdentity returns handler identity
@@ -35,39 +105,251 @@ func (h *Handler) GetIdentity() string {
 
 // Start will start all http handlers
 func (h *Handler) Start() error {
-	http.Handle("/v1/todo", TraceMiddleware(h))
+	handler := RequestIDMiddleware(TraceMiddleware(TimeoutMiddleware(h.options.RequestTimeout, AuthMiddleware(h.options.Authenticator, h))))
+	http.Handle("/v1/todo", handler)
+	http.Handle("/v1/todo/", handler)
+	http.Handle("/v1/lists", handler)
+	http.Handle("/v1/lists/", handler)
+
+	h.mu.Lock()
+	h.started = true
+	h.mu.Unlock()
+	return nil
+}
+
+// Health reports whether the handler is serving requests
+func (h *Handler) Health() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.stopping:
+		return errors.New("shutting down")
+	case !h.started:
+		return errors.New("not started")
+	}
 	return nil
 }
 
+// Stop waits for the request goroutines still running, so the store is not
+// closed under them, and rejects requests arriving meanwhile. It gives up
+// with ctx's error once ctx is done.
+func (h *Handler) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.stopping = true
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("requests still running: %w", ctx.Err())
+	case <-done:
+		return nil
+	}
+}
+
+// TraceMiddleware serves every request in a server span, continuing the
+// trace given in the W3C traceparent header or starting a new one. The span
+// is carried in the request context, so service and store spans become its
+// children, and its trace id is added to the log records of the request.
 func TraceMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
-		r = r.WithContext(ctx)
-		next.ServeHTTP(w, r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path)),
+		)
+		defer span.End()
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx = logging.NewContext(ctx, logging.FromContext(ctx).With("trace_id", spanContext.TraceID().String()))
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDMiddleware tags every request with the ID given in the
+// X-Request-ID header, or a new one, echoes it in the response and stores
+// it in the request context along with a logger adding it to every record
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(logging.RequestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(logging.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
 	})
 }
 
-func (h *Handler) errorResponse(w http.ResponseWriter, code int) {
+// AuthMiddleware answers 401 to requests authenticator does not
+// authenticate and serves the others with the principal in the request
+// context. It runs inside TraceMiddleware so the span and log records of the
+// request name the principal. A nil authenticator serves every request.
+func AuthMiddleware(authenticator *auth.Authenticator, next http.Handler) http.Handler {
+	if authenticator == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticator.Authenticate(r.Context(), r)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="todo-service"`)
+			}
+			errorResponse(r.Context(), w, err)
+			return
+		}
+		trace.SpanFromContext(r.Context()).SetAttributes(semconv.EnduserID(principal.Subject))
+		ctx := logging.NewContext(r.Context(), logging.FromContext(r.Context()).With("subject", principal.Subject))
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(ctx, principal)))
+	})
+}
 
+// TimeoutMiddleware gives every request a deadline of timeout, store queries
+// still running when it expires are cancelled
+func TimeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		h.HandleCreateRequest(w, r)
-	case http.MethodGet:
-		h.HandleGetRequest(w, r)
-	case http.MethodPut:
-		h.HandlePutRequest(w, r)
-	case http.MethodDelete:
-		h.HandleDeleteRequest(w, r)
-	default:
+	start := time.Now()
+	route, params := h.router.match(r.URL.Path)
+	if route == nil {
+		err := fmt.Errorf("%w: no resource at %s", todo.ErrNotFound, r.URL.Path)
+		h.record(r, unmatchedRoute, errorResponse(r.Context(), w, err), start)
+		return
+	}
+
+	fn, ok := route.methods[r.Method]
+	if !ok {
 		// Return error immediately if the request method is incorrect
-		h.errorResponse(w, http.StatusMethodNotAllowed)
+		w.Header().Set("Allow", route.allow())
+		err := fmt.Errorf("%w: method %s is not supported on %s", errMethodNotAllowed, r.Method, r.URL.Path)
+		h.record(r, route.pattern, errorResponse(r.Context(), w, err), start)
+		return
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+	}
+	fn(w, withRoute(r, route.pattern, params))
+}
+
+// maxBodyBytes returns the request body limit of the handler
+func (h *Handler) maxBodyBytes() int64 {
+	if h.options.MaxBodyBytes > 0 {
+		return h.options.MaxBodyBytes
 	}
+	return DefaultMaxBodyBytes
+}
+
+// unmatchedRoute is the route label of requests to paths no route matches
+const unmatchedRoute = "unmatched"
+
+// record logs a request to route answered with status, records it in the
+// metrics and names its span after the route
+func (h *Handler) record(r *http.Request, route string, status int, start time.Time) {
+	duration := time.Since(start)
+	h.options.Metrics.Observe(route, r.Method, status, duration)
+
+	span := trace.SpanFromContext(r.Context())
+	span.SetName(r.Method + " " + route)
+	span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	logging.FromContext(r.Context()).Log(r.Context(), level, "request served",
+		"method", r.Method, "route", route, "path", r.URL.Path, "status", status, "duration_ms", duration.Milliseconds())
+}
+
+// serve runs fn in its own goroutine and writes either its response or
+// the error it returned. The request is abandoned once the request context
+// is done, with errRequestTimeOut if its deadline expired.
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context) (response, error)) {
+	ctx := r.Context()
+	var err error
+	var resp response
+	errChan := make(chan error, 1)
+	defer func(start time.Time) {
+		if err != nil {
+			h.record(r, routePattern(r), errorResponse(r.Context(), w, err), start)
+			return
+		}
+		var jsonResponse []byte
+		if resp.body != nil {
+			jsonResponse, _ = json.Marshal(resp.body)
+		}
+		decorators := append([]httputil.ResponseDecorator{httputil.NewContentTypeDecorator("application/json")}, resp.decorators...)
+		httputil.WriteResponse(w, jsonResponse, resp.status, decorators...)
+		h.record(r, routePattern(r), resp.status, start)
+	}(time.Now())
+
+	if !h.begin() {
+		err = fmt.Errorf("%w: the server is shutting down", todo.ErrUnavailable)
+		return
+	}
+	go func(ctx context.Context) {
+		defer h.inflight.Done()
+		res, err := fn(ctx)
+		resp = res
+		errChan <- err
+	}(ctx)
+
+	select {
+	case <-ctx.Done():
+		err = contextError(ctx.Err())
+	case err = <-errChan:
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			err = contextError(err)
+		}
+	}
+}
+
+// begin counts a new request goroutine, it returns false once the handler is stopping
+func (h *Handler) begin() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopping {
+		return false
+	}
+	h.inflight.Add(1)
+	return true
+}
+
+// parseTodoID returns the {id} path parameter, or 0 when the route has none
+func (h *Handler) parseTodoID(r *http.Request) (int, error) {
+	idParam := pathParam(r, "id")
+	if idParam == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(idParam)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: no task found with id %q", todo.ErrNotFound, idParam)
+	}
+	return id, nil
 }
 
 func (h *Handler) parseTodoQueryParam(ctx context.Context, r *http.Request) ([]int, error) {
+	id, err := h.parseTodoID(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0 {
+		return []int{id}, nil
+	}
 
 	idsParam := r.URL.Query().Get("ids")
 
@@ -80,9 +362,9 @@ func (h *Handler) parseTodoQueryParam(ctx context.Context, r *http.Request) ([]i
 
 	var ids []int
 	for _, idStr := range idStrings {
-		id, err := strconv.Atoi(idStr)
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
 		if err != nil {
-			return nil, err // Handle parsing errors
+			return nil, fmt.Errorf("%w: invalid id %q in ids query parameter", errBadRequest, idStr)
 		}
 		ids = append(ids, id)
 	}
@@ -90,174 +372,379 @@ func (h *Handler) parseTodoQueryParam(ctx context.Context, r *http.Request) ([]i
 	return ids, nil
 }
 
-func (h *Handler) parseTodoRequest(ctx context.Context, r *http.Request) (todo.TodoRequestInput, error) {
-	var inputRequest todo.TodoRequestInput
-	var err error
+// readRequestBody reads the whole request body, giving up once ctx is done
+func (h *Handler) readRequestBody(ctx context.Context, r *http.Request) ([]byte, error) {
+	var body []byte
 	errChan := make(chan error, 1)
 	go func(ctx context.Context) {
-		// Parse request
-		body, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			errChan <- errBadRequest
+		data, err := ioutil.ReadAll(r.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			errChan <- fmt.Errorf("%w: request body must be at most %d bytes", errRequestTooLarge, tooLarge.Limit)
 			return
 		}
-
-		err = json.Unmarshal(body, &inputRequest)
 		if err != nil {
-			errChan <- errBadRequest
+			errChan <- fmt.Errorf("%w: unable to read request body: %v", errBadRequest, err)
 			return
 		}
+		if len(data) == 0 {
+			errChan <- fmt.Errorf("%w: request body is required", errBadRequest)
+			return
+		}
+		body = data
 		errChan <- nil
 	}(ctx)
 
 	select {
 	case <-ctx.Done():
-		err = errRequestTimeOut
-		return inputRequest, nil
-	case err = <-errChan:
+		return nil, contextError(ctx.Err())
+	case err := <-errChan:
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// parseTodoQuery reads the filters, sort and page of a collection GET request
+func (h *Handler) parseTodoQuery(ctx context.Context, r *http.Request) (todo.TodoQuery, error) {
+	var query todo.TodoQuery
+	ids, err := h.parseTodoQueryParam(ctx, r)
+	if err != nil {
+		return query, err
+	}
+	query.Ids = ids
+
+	params := r.URL.Query()
+	if completed := params.Get("completed"); completed != "" {
+		value, err := strconv.ParseBool(completed)
+		if err != nil {
+			return query, fmt.Errorf("%w: completed must be true or false, got %q", errBadRequest, completed)
+		}
+		query.Completed = &value
+	}
+	if limit := params.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			return query, fmt.Errorf("%w: limit must be an integer, got %q", errBadRequest, limit)
+		}
+		query.Limit = value
+	}
+	if listID := params.Get("list_id"); listID != "" {
+		value, err := strconv.Atoi(listID)
+		if err != nil {
+			return query, fmt.Errorf("%w: list_id must be an integer, got %q", errBadRequest, listID)
+		}
+		query.ListId = value
+	}
+	query.Q = params.Get("q")
+	query.Owner = params.Get("owner")
+	query.Sort = params.Get("sort")
+	query.Cursor = params.Get("cursor")
+	return query, nil
+}
+
+// parseTodoSearchQuery reads the search terms and filters of a search request
+func (h *Handler) parseTodoSearchQuery(ctx context.Context, r *http.Request) (todo.TodoSearchQuery, error) {
+	params := r.URL.Query()
+	query := todo.TodoSearchQuery{Q: params.Get("q")}
+	if completed := params.Get("completed"); completed != "" {
+		value, err := strconv.ParseBool(completed)
+		if err != nil {
+			return query, fmt.Errorf("%w: completed must be true or false, got %q", errBadRequest, completed)
+		}
+		query.Completed = &value
+	}
+	if limit := params.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
 		if err != nil {
-			return inputRequest, err
+			return query, fmt.Errorf("%w: limit must be an integer, got %q", errBadRequest, limit)
 		}
+		query.Limit = value
 	}
+	return query, nil
+}
 
+func (h *Handler) parseTodoRequest(ctx context.Context, r *http.Request) (todo.TodoRequestInput, error) {
+	var inputRequest todo.TodoRequestInput
+	id, err := h.parseTodoID(r)
+	if err != nil {
+		return inputRequest, err
+	}
+
+	body, err := h.readRequestBody(ctx, r)
+	if err != nil {
+		return inputRequest, err
+	}
+	if err := decodeBody(body, &inputRequest); err != nil {
+		return todo.TodoRequestInput{}, err
+	}
+
+	if id != 0 {
+		if inputRequest.Id != 0 && inputRequest.Id != id {
+			return todo.TodoRequestInput{}, fmt.Errorf("%w: id %d in body does not match id %d in path", errBadRequest, inputRequest.Id, id)
+		}
+		inputRequest.Id = id
+	}
 	return inputRequest, nil
 }
 
+// decodeBody decodes a JSON request body into v. Unknown fields and values
+// of the wrong type are reported as validation errors, anything else that is
+// not a single JSON value as a bad request.
+func decodeBody(body []byte, v interface{}) error {
+	err := todo.DecodeJSON(body, v)
+	if err != nil && !errors.Is(err, todo.ErrValidation) {
+		return fmt.Errorf("%w: %v", errBadRequest, err)
+	}
+	return err
+}
+
+// parseMergePatch returns the JSON merge patch document sent with a PATCH request
+func (h *Handler) parseMergePatch(ctx context.Context, r *http.Request) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		return nil, fmt.Errorf("%w: PATCH requires Content-Type application/merge-patch+json", errUnsupportedMedia)
+	}
+
+	body, err := h.readRequestBody(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	var patch interface{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON body: %v", errBadRequest, err)
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%w: merge patch must be a JSON object", errBadRequest)
+	}
+	return body, nil
+}
+
 func (h *Handler) HandleCreateRequest(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var inputRequestData todo.TodoRequestInput
-	var err error
-	errChan := make(chan error, 1)
-	var response todo.TodoResponse
-	defer func(start time.Time) {
-		jsonResponse, _ := json.Marshal(response)
-		_, err := httputil.WriteResponse(w, jsonResponse, http.StatusOK, httputil.NewContentTypeDecorator("application/json"))
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		inputRequestData, err := h.parseTodoRequest(ctx, r)
 		if err != nil {
-			return
+			return response{}, err
 		}
-	}(time.Now())
 
-	go func(ctx context.Context) {
-		inputRequestData, err = h.parseTodoRequest(ctx, r)
+		todoResponse, err := h.service.TodoCreateRequest(ctx, inputRequestData)
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
 		}
+		return response{
+			status: http.StatusCreated,
+			body:   todoResponse,
+			decorators: append(etagDecorators(todoResponse),
+				httputil.NewHeaderDecorator("Location", fmt.Sprintf("/v1/todo/%d", todoResponse.Id)),
+			),
+		}, nil
+	})
+}
 
-		response, err = h.service.TodoCreateRequest(ctx, inputRequestData)
+func (h *Handler) HandleGetRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		query, err := h.parseTodoQuery(ctx, r)
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
 		}
-		errChan <- nil
-	}(ctx)
-
-	select {
-	case <-ctx.Done():
-		return
-	case err = <-errChan:
-		return
-	}
 
+		todoResponse, err := h.service.TodoGetRequest(ctx, query)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: todoResponse}, nil
+	})
 }
 
-func (h *Handler) HandleGetRequest(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var err error
-	errChan := make(chan error, 1)
-	var response []todo.TodoResponse
-	defer func(start time.Time) {
-		jsonResponse, _ := json.Marshal(response)
-		_, err := httputil.WriteResponse(w, jsonResponse, http.StatusOK, httputil.NewContentTypeDecorator("application/json"))
+func (h *Handler) HandleGetItemRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseTodoID(r)
 		if err != nil {
-			return
+			return response{}, err
 		}
-	}(time.Now())
-	go func(ctx context.Context) {
-		var ids []int
-		ids, err = h.parseTodoQueryParam(ctx, r)
+
+		todoResponse, err := h.service.TodoGetRequest(ctx, todo.TodoQuery{Ids: []int{id}})
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
+		}
+		if len(todoResponse.Items) == 0 {
+			return response{}, fmt.Errorf("%w: no task found with id %d", todo.ErrNotFound, id)
 		}
+		item := todoResponse.Items[0]
+		if notModified(r, item.Version) {
+			return response{status: http.StatusNotModified, decorators: etagDecorators(item)}, nil
+		}
+		return response{status: http.StatusOK, body: item, decorators: etagDecorators(item)}, nil
+	})
+}
 
-		response = h.service.TodoGetRequest(ctx, ids)
-		errChan <- nil
-	}(ctx)
+func (h *Handler) HandleSearchRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		query, err := h.parseTodoSearchQuery(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
 
-	select {
-	case <-ctx.Done():
-		return
-	case err = <-errChan:
-		return
-	}
+		searchResponse, err := h.service.TodoSearchRequest(ctx, query)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: searchResponse}, nil
+	})
 }
 
 func (h *Handler) HandlePutRequest(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var inputRequestData todo.TodoRequestInput
-	var err error
-	errChan := make(chan error, 1)
-	var response todo.TodoResponse
-	defer func(start time.Time) {
-		jsonResponse, _ := json.Marshal(response)
-		_, err := httputil.WriteResponse(w, jsonResponse, http.StatusOK, httputil.NewContentTypeDecorator("application/json"))
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		inputRequestData, err := h.parseTodoRequest(ctx, r)
 		if err != nil {
-			return
+			return response{}, err
+		}
+		// A version in the body is a precondition of its own
+		if inputRequestData.Version == 0 || r.Header.Get("If-Match") != "" {
+			version, err := h.ifMatchVersion(r)
+			if err != nil {
+				return response{}, err
+			}
+			if version != 0 && inputRequestData.Version != 0 && inputRequestData.Version != version {
+				return response{}, fmt.Errorf("%w: version %d in body does not match If-Match %s", errBadRequest, inputRequestData.Version, etag(version))
+			}
+			if version != 0 {
+				inputRequestData.Version = version
+			}
 		}
-	}(time.Now())
 
-	go func(ctx context.Context) {
-		inputRequestData, err = h.parseTodoRequest(ctx, r)
+		todoResponse, err := h.service.TodoUpdateRequest(ctx, inputRequestData)
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
 		}
+		return response{status: http.StatusOK, body: todoResponse, decorators: etagDecorators(todoResponse)}, nil
+	})
+}
 
-		response = h.service.TodoUpdateRequest(ctx, inputRequestData)
+func (h *Handler) HandlePatchRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseTodoID(r)
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
+		}
+		version, err := h.ifMatchVersion(r)
+		if err != nil {
+			return response{}, err
+		}
+		patch, err := h.parseMergePatch(ctx, r)
+		if err != nil {
+			return response{}, err
 		}
-		errChan <- nil
-	}(ctx)
 
-	select {
-	case <-ctx.Done():
-		return
-	case err = <-errChan:
-		return
-	}
+		todoResponse, err := h.service.TodoPatchRequest(ctx, id, patch, version)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: todoResponse, decorators: etagDecorators(todoResponse)}, nil
+	})
 }
 
 func (h *Handler) HandleDeleteRequest(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	var err error
-	errChan := make(chan error, 1)
-	var response todo.TodoResponse
-	defer func(start time.Time) {
-		jsonResponse, _ := json.Marshal(response)
-		_, err := httputil.WriteResponse(w, jsonResponse, http.StatusOK, httputil.NewContentTypeDecorator("application/json"))
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		ids, err := h.parseTodoQueryParam(ctx, r)
 		if err != nil {
-			return
+			return response{}, err
 		}
-	}(time.Now())
-	go func(ctx context.Context) {
-		var ids []int
-		ids, err = h.parseTodoQueryParam(ctx, r)
+		// Deleting several tasks at once takes no precondition
+		version := 0
+		if len(ids) == 1 || r.Header.Get("If-Match") != "" {
+			if version, err = h.ifMatchVersion(r); err != nil {
+				return response{}, err
+			}
+		}
+
+		todoResponse, err := h.service.TodoDeleteRequest(ctx, ids, version)
 		if err != nil {
-			errChan <- err
-			return
+			return response{}, err
 		}
+		return response{status: http.StatusOK, body: todoResponse}, nil
+	})
+}
 
-		response = h.service.TodoDeleteRequest(ctx, ids)
-		errChan <- nil
-	}(ctx)
+// HandleTrashRequest lists the deleted tasks with the filters, sort and
+// pages of a collection GET request
+func (h *Handler) HandleTrashRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		query, err := h.parseTodoQuery(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+		query.Deleted = true
 
-	select {
-	case <-ctx.Done():
-		return
-	case err = <-errChan:
-		return
+		todoResponse, err := h.service.TodoGetRequest(ctx, query)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: todoResponse}, nil
+	})
+}
+
+func (h *Handler) HandleRestoreRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseTodoID(r)
+		if err != nil {
+			return response{}, err
+		}
+
+		todoResponse, err := h.service.TodoRestoreRequest(ctx, id)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: todoResponse, decorators: etagDecorators(todoResponse)}, nil
+	})
+}
+
+// HandleBatchRequest applies a list of create, update and delete operations
+// in one transaction. It answers 200 with the result of every operation once
+// the batch is committed. An atomic batch rolled back because an operation
+// failed is answered with the status of that operation.
+func (h *Handler) HandleBatchRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		body, err := h.readRequestBody(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+		var request todo.TodoBatchRequest
+		if err := decodeBody(body, &request); err != nil {
+			return response{}, err
+		}
+
+		batchResponse, err := h.service.TodoBatchRequest(ctx, request)
+		if err != nil {
+			return response{}, err
+		}
+		status := http.StatusOK
+		for i := range batchResponse.Results {
+			result := &batchResponse.Results[i]
+			result.Status = batchResultStatus(*result)
+			if result.Err != nil {
+				result.Error = result.Err.Error()
+				if !batchResponse.Committed && !errors.Is(result.Err, todo.ErrBatchAborted) {
+					status = result.Status
+				}
+			}
+		}
+		return response{status: status, body: batchResponse}, nil
+	})
+}
+
+// batchResultStatus returns the status an operation of a batch would have
+// been answered with as a request of its own
+func batchResultStatus(result todo.TodoBatchResult) int {
+	switch {
+	case errors.Is(result.Err, todo.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case result.Err != nil:
+		_, status := errorStatus(result.Err)
+		return status
+	case result.Op == todo.OpCreate:
+		return http.StatusCreated
 	}
+	return http.StatusOK
 }
@@ -0,0 +1,656 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/metrics"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo/service"
+	"github.com/RanbirSingh-Velotio/todo-service/store/memory"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubVersion is the version of every task of stubService, writes naming
+// another one fail and leave the task at stubVersion+1
+const stubVersion = 3
+
+// stubPrecondition fails like a store when version names another version than stubVersion
+func stubPrecondition(version int) error {
+	if version != 0 && version != stubVersion {
+		return fmt.Errorf("%w: task is at version %d", todo.ErrPreconditionFailed, stubVersion)
+	}
+	return nil
+}
+
+type stubService struct {
+	err error
+	// started is closed once TodoGetRequest runs, release, when set,
+	// holds it until release is closed
+	started chan struct{}
+	release chan struct{}
+	// batch, when set, is returned by TodoBatchRequest
+	batch *todo.TodoBatchResponse
+}
+
+func (s *stubService) TodoCreateRequest(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	return todo.TodoResponse{Id: requestInput.Id, Name: requestInput.Name}, s.err
+}
+
+func (s *stubService) TodoGetRequest(ctx context.Context, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	if s.release != nil {
+		close(s.started)
+		<-s.release
+	}
+	if errors.Is(s.err, context.DeadlineExceeded) || errors.Is(s.err, context.Canceled) {
+		<-ctx.Done()
+		return todo.TodoListResponse{}, ctx.Err()
+	}
+	items := []todo.TodoResponse{}
+	for _, id := range query.Ids {
+		items = append(items, todo.TodoResponse{Id: id, Version: stubVersion})
+	}
+	return todo.TodoListResponse{Items: items}, s.err
+}
+
+func (s *stubService) TodoDeleteRequest(ctx context.Context, ids []int, version int) (todo.TodoDeleteResponse, error) {
+	if err := stubPrecondition(version); err != nil {
+		return todo.TodoDeleteResponse{}, err
+	}
+	return todo.NewTodoDeleteResponse(ids, ids), s.err
+}
+
+func (s *stubService) TodoBatchRequest(ctx context.Context, request todo.TodoBatchRequest) (todo.TodoBatchResponse, error) {
+	if s.batch != nil {
+		return *s.batch, s.err
+	}
+	return todo.TodoBatchResponse{Mode: request.Mode, Committed: true}, s.err
+}
+
+func (s *stubService) TodoRestoreRequest(ctx context.Context, id int) (todo.TodoResponse, error) {
+	return todo.TodoResponse{Id: id}, s.err
+}
+
+func (s *stubService) TodoPurgeRequest(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return 0, s.err
+}
+
+func (s *stubService) TodoUpdateRequest(ctx context.Context, input todo.TodoRequestInput) (todo.TodoResponse, error) {
+	if err := stubPrecondition(input.Version); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	return todo.TodoResponse{Id: input.Id, Name: input.Name, Version: stubVersion + 1}, s.err
+}
+
+func (s *stubService) TodoPatchRequest(ctx context.Context, id int, patch []byte, version int) (todo.TodoResponse, error) {
+	if err := stubPrecondition(version); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	return todo.TodoResponse{Id: id, Version: stubVersion + 1}, s.err
+}
+
+func (s *stubService) TodoSearchRequest(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error) {
+	return todo.TodoSearchResponse{Items: []todo.TodoSearchResult{}}, s.err
+}
+
+func (s *stubService) ListCreateRequest(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: 5, Name: input.Name}, s.err
+}
+
+func (s *stubService) ListGetRequest(ctx context.Context, id int) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: id}, s.err
+}
+
+func (s *stubService) ListsGetRequest(ctx context.Context) (todo.ListsResponse, error) {
+	return todo.ListsResponse{Items: []todo.ListResponse{}}, s.err
+}
+
+func (s *stubService) ListUpdateRequest(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: input.Id, Name: input.Name}, s.err
+}
+
+func (s *stubService) ListDeleteRequest(ctx context.Context, id int) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: id}, s.err
+}
+
+func (s *stubService) ListMemberPutRequest(ctx context.Context, id int, member todo.ListMember) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: id, Members: []todo.ListMember{member}}, s.err
+}
+
+func (s *stubService) ListMemberDeleteRequest(ctx context.Context, id int, subject string) (todo.ListResponse, error) {
+	return todo.ListResponse{Id: id, Members: []todo.ListMember{}}, s.err
+}
+
+func (s *stubService) ListTodosRequest(ctx context.Context, id int, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	items := []todo.TodoResponse{}
+	for _, taskID := range query.Ids {
+		items = append(items, todo.TodoResponse{Id: taskID, ListId: id})
+	}
+	return todo.TodoListResponse{Items: items}, s.err
+}
+
+func TestHandler_ServeHTTP_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		serviceErr error
+		wantStatus int
+		wantCode   string
+	}{
+		{"bad json", http.MethodPost, "/v1/todo", `{"name":`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"empty body", http.MethodPut, "/v1/todo", ``, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"bad id", http.MethodGet, "/v1/todo?ids=1,x", ``, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"bad completed", http.MethodGet, "/v1/todo?completed=maybe", ``, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"bad limit", http.MethodGet, "/v1/todo?limit=ten", ``, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"method not allowed", http.MethodPatch, "/v1/todo", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"not found", http.MethodGet, "/v1/todo?ids=1", ``, fmt.Errorf("%w: no task", todo.ErrNotFound), http.StatusNotFound, "NOT_FOUND"},
+		{"conflict", http.MethodPost, "/v1/todo", `{"name":"a"}`, fmt.Errorf("%w: exists", todo.ErrConflict), http.StatusConflict, "CONFLICT"},
+		{"validation", http.MethodPost, "/v1/todo", `{"name":""}`, fmt.Errorf("%w: name is required", todo.ErrValidation), http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"internal", http.MethodDelete, "/v1/todo?ids=1", ``, fmt.Errorf("disk I/O error"), http.StatusInternalServerError, "INTERNAL_ERROR"},
+		{"unknown route", http.MethodGet, "/v1/todo/1/comments", ``, nil, http.StatusNotFound, "NOT_FOUND"},
+		{"non numeric id", http.MethodGet, "/v1/todo/abc", ``, nil, http.StatusNotFound, "NOT_FOUND"},
+		{"item method not allowed", http.MethodPost, "/v1/todo/1", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"mismatched body id", http.MethodPut, "/v1/todo/1", `{"id":2,"name":"a"}`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"patch without content type", http.MethodPatch, "/v1/todo/1", `{"completed":true}`, nil, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"search method not allowed", http.MethodDelete, "/v1/todo/search", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"restore not in trash", http.MethodPost, "/v1/todo/1/restore", ``, fmt.Errorf("%w: no task with id 1 in the trash", todo.ErrNotFound), http.StatusNotFound, "NOT_FOUND"},
+		{"restore method not allowed", http.MethodGet, "/v1/todo/1/restore", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"batch bad json", http.MethodPost, "/v1/todo/batch", `[`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"unknown field", http.MethodPost, "/v1/todo", `{"name":"a","owner":"b"}`, nil, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"wrong type", http.MethodPut, "/v1/todo/1", `{"name":7}`, nil, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"trailing data", http.MethodPost, "/v1/todo", `{"name":"a"}{}`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"body too large", http.MethodPost, "/v1/todo", `{"name":"` + strings.Repeat("a", DefaultMaxBodyBytes) + `"}`, nil, http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE"},
+		{"batch unknown field", http.MethodPost, "/v1/todo/batch", `{"operations":[{"op":"create","todo":{"title":"a"}}]}`, nil, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"batch validation", http.MethodPost, "/v1/todo/batch", `{"operations":[]}`, fmt.Errorf("%w: operations is required", todo.ErrValidation), http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"trash method not allowed", http.MethodDelete, "/v1/todo/trash", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"unavailable", http.MethodPost, "/v1/todo", `{"name":"a"}`, fmt.Errorf("%w: database is locked", todo.ErrUnavailable), http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+		{"timeout", http.MethodGet, "/v1/todo", ``, context.DeadlineExceeded, http.StatusGatewayTimeout, "REQUEST_TIMEOUT"},
+		{"cancelled", http.MethodGet, "/v1/todo", ``, context.Canceled, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+		{"forbidden", http.MethodPut, "/v1/todo/1", `{"name":"a"}`, fmt.Errorf("%w: viewers cannot change tasks", todo.ErrForbidden), http.StatusForbidden, "FORBIDDEN"},
+		{"bad list_id", http.MethodGet, "/v1/todo?list_id=x", ``, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"non numeric list id", http.MethodGet, "/v1/lists/abc", ``, nil, http.StatusNotFound, "NOT_FOUND"},
+		{"list method not allowed", http.MethodPatch, "/v1/lists/1", ``, nil, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED"},
+		{"list unknown field", http.MethodPost, "/v1/lists", `{"name":"a","members":[]}`, nil, http.StatusUnprocessableEntity, "VALIDATION_FAILED"},
+		{"mismatched body list id", http.MethodPut, "/v1/lists/1", `{"id":2,"name":"a"}`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"mismatched member subject", http.MethodPut, "/v1/lists/1/members/bob", `{"subject":"eve","role":"viewer"}`, nil, http.StatusBadRequest, "BAD_REQUEST"},
+		{"list todos not found", http.MethodGet, "/v1/lists/9/todos", ``, fmt.Errorf("%w: no list found with id 9", todo.ErrNotFound), http.StatusNotFound, "NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h http.Handler = InitHandler(&stubService{err: tt.serviceErr}, Options{})
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			switch tt.serviceErr {
+			case context.DeadlineExceeded:
+				h = TimeoutMiddleware(time.Millisecond, h)
+			case context.Canceled:
+				ctx, cancel := context.WithCancel(r.Context())
+				cancel()
+				r = r.WithContext(ctx)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			result := w.Result()
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", result.StatusCode, tt.wantStatus)
+				return
+			}
+			var stdErr httputil.StandardError
+			if err := json.NewDecoder(result.Body).Decode(&stdErr); err != nil {
+				t.Errorf("ServeHTTP() decode body err = %v\n", err)
+				return
+			}
+			if stdErr.Code != tt.wantCode {
+				t.Errorf("ServeHTTP() code = %v, want %v\n", stdErr.Code, tt.wantCode)
+			}
+			if len(stdErr.Object.Text) == 0 {
+				t.Errorf("ServeHTTP() object.text is empty\n")
+			}
+			if retryAfter := result.Header.Get("Retry-After"); (tt.wantStatus == http.StatusServiceUnavailable) != (retryAfter != "") {
+				t.Errorf("ServeHTTP() Retry-After = %q for status %v\n", retryAfter, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_FieldErrors(t *testing.T) {
+	var v todo.Validator
+	v.Add("name", "is required")
+	v.Add("priority", "must be one of low, medium, high")
+	tests := []struct {
+		name       string
+		serviceErr error
+		body       string
+		wantText   []string
+	}{
+		{"every field", v.Err(), `{"name":""}`, []string{"name is required", "priority must be one of low, medium, high"}},
+		{"unknown field", nil, `{"name":"a","colour":"red"}`, []string{"colour is not a known field"}},
+		{"nested wrong type", nil, `{"operations":[{"op":"create","todo":{"name":1}}]}`, []string{"operations[0].todo.name must be of type string, got number"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/v1/todo"
+			if strings.Contains(tt.body, "operations") {
+				target = "/v1/todo/batch"
+			}
+			h := InitHandler(&stubService{err: tt.serviceErr}, Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(tt.body)))
+
+			var stdErr httputil.StandardError
+			if err := json.NewDecoder(w.Result().Body).Decode(&stdErr); err != nil {
+				t.Errorf("ServeHTTP() decode body err = %v\n", err)
+				return
+			}
+			if w.Code != http.StatusUnprocessableEntity || strings.Join(stdErr.Object.Text, "|") != strings.Join(tt.wantText, "|") {
+				t.Errorf("ServeHTTP() = %v %v, want %v %v\n", w.Code, stdErr.Object.Text, http.StatusUnprocessableEntity, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_MaxBodyBytes(t *testing.T) {
+	h := InitHandler(&stubService{}, Options{MaxBodyBytes: 16})
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"within limit", `{"name":"a"}`, http.StatusCreated},
+		{"over limit", `{"name":"abcdefghijk"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/todo", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Allow(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"collection", "/v1/todo", "DELETE, GET, POST, PUT"},
+		{"item", "/v1/todo/1", "DELETE, GET, PATCH, PUT"},
+		{"trash", "/v1/todo/trash", "GET"},
+		{"restore", "/v1/todo/1/restore", "POST"},
+		{"lists", "/v1/lists", "GET, POST"},
+		{"list", "/v1/lists/1", "DELETE, GET, PUT"},
+		{"list todos", "/v1/lists/1/todos", "GET"},
+		{"list member", "/v1/lists/1/members/bob", "DELETE, PUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := InitHandler(&stubService{}, Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tt.target, nil))
+			if got := w.Result().Header.Get("Allow"); got != tt.want {
+				t.Errorf("ServeHTTP() Allow = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Item(t *testing.T) {
+	h := InitHandler(&stubService{}, Options{})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/todo/7", strings.NewReader(`{"name":"a"}`)))
+
+	result := w.Result()
+	if result.StatusCode != http.StatusOK {
+		t.Errorf("ServeHTTP() status = %v, want %v\n", result.StatusCode, http.StatusOK)
+		return
+	}
+	var got todo.TodoResponse
+	if err := json.NewDecoder(result.Body).Decode(&got); err != nil {
+		t.Errorf("ServeHTTP() decode body err = %v\n", err)
+		return
+	}
+	if got.Id != 7 {
+		t.Errorf("ServeHTTP() id = %v, want %v\n", got.Id, 7)
+	}
+}
+
+func TestHandler_ServeHTTP_Create(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{"created", `{"name":"buy milk"}`, http.StatusCreated, "/v1/todo/1", `"id":1,"name":"buy milk"`},
+		{"id set by the client", `{"id":7,"name":"buy milk"}`, http.StatusUnprocessableEntity, "", `"code":"VALIDATION_FAILED"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := InitHandler(service.New(memory.New()), Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/todo", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("ServeHTTP() Location = %q, want %q\n", got, tt.wantLocation)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("ServeHTTP() body = %s, want it to hold %s\n", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Lists(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{"create", http.MethodPost, "/v1/lists", `{"name":"home"}`, http.StatusCreated, "/v1/lists/5", `"name":"home"`},
+		{"update", http.MethodPut, "/v1/lists/3", `{"name":"work"}`, http.StatusOK, "", `"id":3,"name":"work"`},
+		{"member from path", http.MethodPut, "/v1/lists/3/members/bob", `{"role":"editor"}`, http.StatusOK, "", `"members":[{"subject":"bob","role":"editor"}]`},
+		{"todos", http.MethodGet, "/v1/lists/3/todos?ids=4", ``, http.StatusOK, "", `"id":4,"list_id":3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := InitHandler(&stubService{}, Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("ServeHTTP() Location = %q, want %q\n", got, tt.wantLocation)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("ServeHTTP() body = %s, want it to hold %s\n", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Batch(t *testing.T) {
+	notFound := fmt.Errorf("%w: no task found with id 9", todo.ErrNotFound)
+	aborted := fmt.Errorf("%w: operation 1 failed", todo.ErrBatchAborted)
+	tests := []struct {
+		name         string
+		batch        todo.TodoBatchResponse
+		wantStatus   int
+		wantStatuses []int
+	}{
+		{"committed", todo.TodoBatchResponse{Committed: true, Results: []todo.TodoBatchResult{
+			{Op: todo.OpCreate}, {Op: todo.OpUpdate, Err: notFound}, {Op: todo.OpDelete},
+		}}, http.StatusOK, []int{http.StatusCreated, http.StatusNotFound, http.StatusOK}},
+		{"rolled back", todo.TodoBatchResponse{Results: []todo.TodoBatchResult{
+			{Op: todo.OpCreate, Err: aborted}, {Op: todo.OpUpdate, Err: notFound}, {Op: todo.OpDelete, Err: aborted},
+		}}, http.StatusNotFound, []int{http.StatusFailedDependency, http.StatusNotFound, http.StatusFailedDependency}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := InitHandler(&stubService{batch: &tt.batch}, Options{})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/todo/batch", strings.NewReader(`{"operations":[]}`)))
+
+			result := w.Result()
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", result.StatusCode, tt.wantStatus)
+			}
+			var got todo.TodoBatchResponse
+			if err := json.NewDecoder(result.Body).Decode(&got); err != nil {
+				t.Errorf("ServeHTTP() decode body err = %v\n", err)
+				return
+			}
+			var statuses []int
+			for _, r := range got.Results {
+				statuses = append(statuses, r.Status)
+				if (r.Status >= 400) != (r.Error != "") {
+					t.Errorf("ServeHTTP() result %+v, want an error exactly for failed operations\n", r)
+				}
+			}
+			if fmt.Sprint(statuses) != fmt.Sprint(tt.wantStatuses) {
+				t.Errorf("ServeHTTP() statuses = %v, want %v\n", statuses, tt.wantStatuses)
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Preconditions(t *testing.T) {
+	tests := []struct {
+		name           string
+		requireIfMatch bool
+		method         string
+		target         string
+		body           string
+		header         map[string]string
+		wantStatus     int
+		wantETag       string
+	}{
+		{"get", false, http.MethodGet, "/v1/todo/7", ``, nil, http.StatusOK, `"3"`},
+		{"get not modified", false, http.MethodGet, "/v1/todo/7", ``, map[string]string{"If-None-Match": `"1", W/"3"`}, http.StatusNotModified, `"3"`},
+		{"get modified", false, http.MethodGet, "/v1/todo/7", ``, map[string]string{"If-None-Match": `"2"`}, http.StatusOK, `"3"`},
+		{"put", true, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, map[string]string{"If-Match": `"3"`}, http.StatusOK, `"4"`},
+		{"put stale", true, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, map[string]string{"If-Match": `"2"`}, http.StatusPreconditionFailed, ""},
+		{"put any version", true, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, map[string]string{"If-Match": `*`}, http.StatusOK, `"4"`},
+		{"put version in body", true, http.MethodPut, "/v1/todo/7", `{"name":"a","version":3}`, nil, http.StatusOK, `"4"`},
+		{"put versions differ", false, http.MethodPut, "/v1/todo/7", `{"name":"a","version":2}`, map[string]string{"If-Match": `"3"`}, http.StatusBadRequest, ""},
+		{"put without if-match", true, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, nil, http.StatusPreconditionRequired, ""},
+		{"put optional if-match", false, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, nil, http.StatusOK, `"4"`},
+		{"put malformed if-match", false, http.MethodPut, "/v1/todo/7", `{"name":"a"}`, map[string]string{"If-Match": `"3", "4"`}, http.StatusBadRequest, ""},
+		{"patch stale", true, http.MethodPatch, "/v1/todo/7", `{"completed":true}`, map[string]string{"If-Match": `"2"`, "Content-Type": "application/merge-patch+json"}, http.StatusPreconditionFailed, ""},
+		{"patch without if-match", true, http.MethodPatch, "/v1/todo/7", `{"completed":true}`, map[string]string{"Content-Type": "application/merge-patch+json"}, http.StatusPreconditionRequired, ""},
+		{"delete", true, http.MethodDelete, "/v1/todo/7", ``, map[string]string{"If-Match": `"3"`}, http.StatusOK, ""},
+		{"delete stale", true, http.MethodDelete, "/v1/todo/7", ``, map[string]string{"If-Match": `"2"`}, http.StatusPreconditionFailed, ""},
+		{"delete without if-match", true, http.MethodDelete, "/v1/todo/7", ``, nil, http.StatusPreconditionRequired, ""},
+		{"bulk delete without if-match", true, http.MethodDelete, "/v1/todo?ids=7,8", ``, nil, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := InitHandler(&stubService{}, Options{RequireIfMatch: tt.requireIfMatch})
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			result := w.Result()
+			if result.StatusCode != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %v, want %v\n", result.StatusCode, tt.wantStatus)
+			}
+			if got := result.Header.Get("ETag"); got != tt.wantETag {
+				t.Errorf("ServeHTTP() ETag = %v, want %v\n", got, tt.wantETag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("ServeHTTP() body = %q, want none\n", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := InitHandler(&stubService{}, Options{Metrics: metrics.NewHTTPMetrics(reg)})
+	for _, r := range []*http.Request{
+		httptest.NewRequest(http.MethodPut, "/v1/todo/7", strings.NewReader(`{"name":"a"}`)),
+		httptest.NewRequest(http.MethodPut, "/v1/todo/8", strings.NewReader(`{"name":"b"}`)),
+		httptest.NewRequest(http.MethodOptions, "/v1/todo/8", nil),
+		httptest.NewRequest(http.MethodGet, "/v1/todo/7/x", nil),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	want := `
+# HELP todo_http_requests_total Number of API requests by route, method and status code.
+# TYPE todo_http_requests_total counter
+todo_http_requests_total{method="GET",route="unmatched",status="404"} 1
+todo_http_requests_total{method="OPTIONS",route="/v1/todo/{id}",status="405"} 1
+todo_http_requests_total{method="PUT",route="/v1/todo/{id}",status="200"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "todo_http_requests_total"); err != nil {
+		t.Errorf("ServeHTTP() metrics: %v\n", err)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"given", "req-42", true},
+		{"missing", "", false},
+		{"invalid", "two words", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = logging.RequestID(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/v1/todo", nil)
+			if tt.header != "" {
+				r.Header.Set(logging.RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if echoed := w.Result().Header.Get(logging.RequestIDHeader); echoed != got || got == "" {
+				t.Errorf("RequestIDMiddleware() header = %v, context = %v, want the same id\n", echoed, got)
+			}
+			if (got == tt.header) != tt.keep {
+				t.Errorf("RequestIDMiddleware() id = %v, given %v\n", got, tt.header)
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	authenticator := auth.NewAuthenticator(nil, auth.StaticKeys{auth.HashAPIKey("ci-key"): {Subject: "ci"}})
+	tests := []struct {
+		name          string
+		authenticator *auth.Authenticator
+		key           string
+		wantStatus    int
+		wantSubject   string
+	}{
+		{"valid key", authenticator, "ci-key", http.StatusOK, "ci"},
+		{"unknown key", authenticator, "other", http.StatusUnauthorized, ""},
+		{"no key", authenticator, "", http.StatusUnauthorized, ""},
+		{"disabled", nil, "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			h := AuthMiddleware(tt.authenticator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := auth.FromContext(r.Context())
+				got = p.Subject
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/v1/todo", nil)
+			if tt.key != "" {
+				r.Header.Set(auth.APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus || got != tt.wantSubject {
+				t.Errorf("AuthMiddleware() = %v %q, want %v %q\n", w.Code, got, tt.wantStatus, tt.wantSubject)
+			}
+			if challenge := w.Result().Header.Get("WWW-Authenticate"); (w.Code == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("AuthMiddleware() WWW-Authenticate = %q for status %v\n", challenge, w.Code)
+			}
+		})
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	h := TraceMiddleware(InitHandler(&stubService{}, Options{}))
+	r := httptest.NewRequest(http.MethodGet, "/v1/todo/7", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("ended spans = %d, want 1\n", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /v1/todo/{id}" {
+		t.Errorf("span name = %v, want %v\n", span.Name(), "GET /v1/todo/{id}")
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span = %v, want a child of the traceparent\n", span.SpanContext())
+	}
+}
+
+func TestHandler_Stop(t *testing.T) {
+	stub := &stubService{started: make(chan struct{}), release: make(chan struct{})}
+	h := InitHandler(stub, Options{})
+	served := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/todo", nil))
+		close(served)
+	}()
+	<-stub.started
+
+	// The request is still running, Stop gives up at the deadline
+	if err := h.Stop(expiredContext(t)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() err = %v, want %v\n", err, context.DeadlineExceeded)
+	}
+
+	// Requests arriving while stopping are turned away
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/todo", nil))
+	if w.Result().StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("ServeHTTP() status = %v, want %v\n", w.Result().StatusCode, http.StatusServiceUnavailable)
+	}
+
+	close(stub.release)
+	<-served
+	if err := h.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() err = %v, want nil\n", err)
+	}
+	if err := h.Health(); err == nil {
+		t.Errorf("Health() err = nil after Stop()\n")
+	}
+}
+
+// expiredContext returns a context whose deadline has passed
+func expiredContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	t.Cleanup(cancel)
+	return ctx
+}
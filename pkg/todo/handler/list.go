@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/httputil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"net/http"
+	"strconv"
+)
+
+// listRoutes registers the list endpoints on the handler's router
+func (h *Handler) listRoutes() {
+	h.router.handle(http.MethodGet, "/v1/lists", h.HandleGetListsRequest)
+	h.router.handle(http.MethodPost, "/v1/lists", h.HandleCreateListRequest)
+
+	h.router.handle(http.MethodGet, "/v1/lists/{list_id}", h.HandleGetListRequest)
+	h.router.handle(http.MethodPut, "/v1/lists/{list_id}", h.HandlePutListRequest)
+	h.router.handle(http.MethodDelete, "/v1/lists/{list_id}", h.HandleDeleteListRequest)
+	h.router.handle(http.MethodGet, "/v1/lists/{list_id}/todos", h.HandleGetListTodosRequest)
+	h.router.handle(http.MethodPut, "/v1/lists/{list_id}/members/{subject}", h.HandlePutMemberRequest)
+	h.router.handle(http.MethodDelete, "/v1/lists/{list_id}/members/{subject}", h.HandleDeleteMemberRequest)
+}
+
+// parseListID returns the {list_id} path parameter
+func (h *Handler) parseListID(r *http.Request) (int, error) {
+	idParam := pathParam(r, "list_id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: no list found with id %q", todo.ErrNotFound, idParam)
+	}
+	return id, nil
+}
+
+// parseListRequest reads the list sent with a POST or PUT request, the id
+// in the path, when there is one, wins over a missing id in the body
+func (h *Handler) parseListRequest(ctx context.Context, r *http.Request) (todo.ListRequestInput, error) {
+	var input todo.ListRequestInput
+	body, err := h.readRequestBody(ctx, r)
+	if err != nil {
+		return input, err
+	}
+	if err := decodeBody(body, &input); err != nil {
+		return todo.ListRequestInput{}, err
+	}
+
+	if pathParam(r, "list_id") != "" {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return todo.ListRequestInput{}, err
+		}
+		if input.Id != 0 && input.Id != id {
+			return todo.ListRequestInput{}, fmt.Errorf("%w: id %d in body does not match id %d in path", errBadRequest, input.Id, id)
+		}
+		input.Id = id
+	}
+	return input, nil
+}
+
+func (h *Handler) HandleGetListsRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		listsResponse, err := h.service.ListsGetRequest(ctx)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listsResponse}, nil
+	})
+}
+
+func (h *Handler) HandleCreateListRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		input, err := h.parseListRequest(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+
+		listResponse, err := h.service.ListCreateRequest(ctx, input)
+		if err != nil {
+			return response{}, err
+		}
+		return response{
+			status:     http.StatusCreated,
+			body:       listResponse,
+			decorators: []httputil.ResponseDecorator{httputil.NewHeaderDecorator("Location", fmt.Sprintf("/v1/lists/%d", listResponse.Id))},
+		}, nil
+	})
+}
+
+func (h *Handler) HandleGetListRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return response{}, err
+		}
+
+		listResponse, err := h.service.ListGetRequest(ctx, id)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listResponse}, nil
+	})
+}
+
+func (h *Handler) HandlePutListRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		input, err := h.parseListRequest(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+
+		listResponse, err := h.service.ListUpdateRequest(ctx, input)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listResponse}, nil
+	})
+}
+
+func (h *Handler) HandleDeleteListRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return response{}, err
+		}
+
+		listResponse, err := h.service.ListDeleteRequest(ctx, id)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listResponse}, nil
+	})
+}
+
+// HandleGetListTodosRequest lists the tasks of a list with the filters,
+// sort and pages of a collection GET request
+func (h *Handler) HandleGetListTodosRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return response{}, err
+		}
+		query, err := h.parseTodoQuery(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+
+		todoResponse, err := h.service.ListTodosRequest(ctx, id, query)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: todoResponse}, nil
+	})
+}
+
+// HandlePutMemberRequest adds the {subject} member to a list, or changes its
+// role, with the role sent in the body
+func (h *Handler) HandlePutMemberRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return response{}, err
+		}
+		body, err := h.readRequestBody(ctx, r)
+		if err != nil {
+			return response{}, err
+		}
+		var member todo.ListMember
+		if err := decodeBody(body, &member); err != nil {
+			return response{}, err
+		}
+		subject := pathParam(r, "subject")
+		if member.Subject != "" && member.Subject != subject {
+			return response{}, fmt.Errorf("%w: subject %q in body does not match subject %q in path", errBadRequest, member.Subject, subject)
+		}
+		member.Subject = subject
+
+		listResponse, err := h.service.ListMemberPutRequest(ctx, id, member)
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listResponse}, nil
+	})
+}
+
+func (h *Handler) HandleDeleteMemberRequest(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r, func(ctx context.Context) (response, error) {
+		id, err := h.parseListID(r)
+		if err != nil {
+			return response{}, err
+		}
+
+		listResponse, err := h.service.ListMemberDeleteRequest(ctx, id, pathParam(r, "subject"))
+		if err != nil {
+			return response{}, err
+		}
+		return response{status: http.StatusOK, body: listResponse}, nil
+	})
+}
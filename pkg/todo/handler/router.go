@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// route binds a handler per HTTP method to a path pattern.
+// Pattern segments wrapped in braces, e.g. {id}, match any single segment
+// and are made available to the handler through pathParam.
+type route struct {
+	pattern  string
+	segments []string
+	methods  map[string]http.HandlerFunc
+}
+
+// router dispatches requests to the route matching their path, so the
+// handler can tell an unknown path (404) from an unsupported method (405).
+type router struct {
+	routes []*route
+}
+
+type routeMatchKey struct{}
+
+// handle registers fn for method on pattern
+func (rt *router) handle(method, pattern string, fn http.HandlerFunc) {
+	for _, r := range rt.routes {
+		if r.pattern == pattern {
+			r.methods[method] = fn
+			return
+		}
+	}
+	rt.routes = append(rt.routes, &route{
+		pattern:  pattern,
+		segments: splitPath(pattern),
+		methods:  map[string]http.HandlerFunc{method: fn},
+	})
+}
+
+// match returns the route matching path along with its path parameters.
+// Routes with literal segments win over parameters, so /v1/todo/search is
+// not mistaken for /v1/todo/{id}.
+func (rt *router) match(path string) (*route, map[string]string) {
+	segments := splitPath(path)
+	var best *route
+	var bestParams map[string]string
+	for _, r := range rt.routes {
+		params, ok := r.match(segments)
+		if !ok {
+			continue
+		}
+		if best == nil || len(params) < len(bestParams) {
+			best, bestParams = r, params
+		}
+	}
+	return best, bestParams
+}
+
+func (r *route) match(segments []string) (map[string]string, bool) {
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, seg := range r.segments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params[strings.Trim(seg, "{}")] = segments[i]
+			continue
+		}
+		if seg != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// allow returns the value of the Allow header for the route
+func (r *route) allow() string {
+	methods := make([]string, 0, len(r.methods))
+	for method := range r.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
+func splitPath(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// routeMatch is the route a request matched along with its path parameters
+type routeMatch struct {
+	pattern string
+	params  map[string]string
+}
+
+// withRoute stores the matched route pattern and path parameters in the request context
+func withRoute(r *http.Request, pattern string, params map[string]string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), routeMatchKey{}, routeMatch{pattern: pattern, params: params}))
+}
+
+// pathParam returns the path parameter name of the matched route
+func pathParam(r *http.Request, name string) string {
+	match, _ := r.Context().Value(routeMatchKey{}).(routeMatch)
+	return match.params[name]
+}
+
+// routePattern returns the pattern of the matched route, e.g. /v1/todo/{id}
+func routePattern(r *http.Request) string {
+	match, _ := r.Context().Value(routeMatchKey{}).(routeMatch)
+	return match.pattern
+}
@@ -0,0 +1,102 @@
+package todo
+
+import (
+	"time"
+)
+
+// Roles a member can hold in a list. Viewers see the list and its tasks,
+// editors also change the list and its tasks, owners also manage the
+// members and delete the list.
+const (
+	ListRoleOwner  = "owner"
+	ListRoleEditor = "editor"
+	ListRoleViewer = "viewer"
+)
+
+// ListRoles lists every list role from most to least privileged
+var ListRoles = []string{ListRoleOwner, ListRoleEditor, ListRoleViewer}
+
+// MaxSubjectLength is the longest subject a list member can have
+const MaxSubjectLength = 255
+
+// ListRoleAtLeast reports whether role grants everything min does, an
+// unknown role grants nothing
+func ListRoleAtLeast(role, min string) bool {
+	rank := func(r string) int {
+		for i, listRole := range ListRoles {
+			if listRole == r {
+				return len(ListRoles) - i
+			}
+		}
+		return 0
+	}
+	return rank(role) > 0 && rank(role) >= rank(min)
+}
+
+type ListRequestInput struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ListMember is a subject and the role it holds in a list
+type ListMember struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+type ListResponse struct {
+	Message     string       `json:"message,omitempty"`
+	Id          int          `json:"id,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Description string       `json:"description,omitempty"`
+	CreatedAt   *time.Time   `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time   `json:"updated_at,omitempty"`
+	Members     []ListMember `json:"members"`
+	// Role is the role of the caller in the list, admins see the lists they
+	// are not a member of without one
+	Role string `json:"role,omitempty"`
+}
+
+// ListsResponse holds the lists the caller is a member of, ordered by id
+type ListsResponse struct {
+	Items []ListResponse `json:"items"`
+}
+
+// Validate checks in against the list field limits and reports every invalid field
+func (in ListRequestInput) Validate() error {
+	var v Validator
+	v.Check("id", Min(in.Id, 0))
+	v.Check("name", Required(in.Name), MaxLength(in.Name, MaxNameLength))
+	v.Check("description", MaxLength(in.Description, MaxDescriptionLength))
+	return v.Err()
+}
+
+// Validate checks the subject and role of m
+func (m ListMember) Validate() error {
+	var v Validator
+	v.Check("subject", Required(m.Subject), MaxLength(m.Subject, MaxSubjectLength))
+	v.Check("role", OneOf(m.Role, ListRoles))
+	return v.Err()
+}
+
+// MemberRole returns the role subject holds in l, or "" when it is not a member
+func (l ListResponse) MemberRole(subject string) string {
+	for _, member := range l.Members {
+		if member.Subject == subject {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// Owners returns how many members of l hold the owner role
+func (l ListResponse) Owners() int {
+	owners := 0
+	for _, member := range l.Members {
+		if member.Role == ListRoleOwner {
+			owners++
+		}
+	}
+	return owners
+}
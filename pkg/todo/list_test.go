@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestListRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		role string
+		min  string
+		want bool
+	}{
+		{ListRoleOwner, ListRoleOwner, true},
+		{ListRoleOwner, ListRoleViewer, true},
+		{ListRoleEditor, ListRoleViewer, true},
+		{ListRoleEditor, ListRoleOwner, false},
+		{ListRoleViewer, ListRoleEditor, false},
+		{"", ListRoleViewer, false},
+		{"admin", ListRoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := ListRoleAtLeast(tt.role, tt.min); got != tt.want {
+			t.Errorf("ListRoleAtLeast(%q, %q) = %v, want %v\n", tt.role, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestListValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{ Validate() error }
+		wantErr bool
+	}{
+		{"valid list", ListRequestInput{Name: "home"}, false},
+		{"missing name", ListRequestInput{Name: " "}, true},
+		{"long name", ListRequestInput{Name: strings.Repeat("a", MaxNameLength+1)}, true},
+		{"negative id", ListRequestInput{Id: -1, Name: "home"}, true},
+		{"valid member", ListMember{Subject: "bob", Role: ListRoleEditor}, false},
+		{"missing subject", ListMember{Role: ListRoleEditor}, true},
+		{"unknown role", ListMember{Subject: "bob", Role: "admin"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v\n", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("Validate() err = %v, want ErrValidation\n", err)
+			}
+		})
+	}
+}
+
+func TestListResponse_Roles(t *testing.T) {
+	list := ListResponse{Members: []ListMember{{"alice", ListRoleOwner}, {"bob", ListRoleEditor}, {"carol", ListRoleOwner}}}
+	if got := list.MemberRole("bob"); got != ListRoleEditor {
+		t.Errorf("MemberRole() = %v, want %v\n", got, ListRoleEditor)
+	}
+	if got := list.MemberRole("dave"); got != "" {
+		t.Errorf("MemberRole() = %v, want none\n", got)
+	}
+	if got := list.Owners(); got != 2 {
+		t.Errorf("Owners() = %v, want 2\n", got)
+	}
+}
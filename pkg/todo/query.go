@@ -0,0 +1,145 @@
+package todo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Fields tasks can be sorted by, a "-" prefix sorts in descending order
+const (
+	SortID        = "id"
+	SortName      = "name"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+)
+
+// SortFields lists every accepted sort field
+var SortFields = []string{SortID, SortName, SortCreatedAt, SortUpdatedAt}
+
+// Page sizes for TodoQuery.Limit
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// TodoQuery selects, orders and pages the tasks returned by TodoGetRequest
+type TodoQuery struct {
+	Ids       []int
+	Completed *bool
+	// Q matches tasks whose name contains it, case insensitively
+	Q      string
+	Sort   string
+	Limit  int
+	Cursor string
+	// Deleted selects the tasks in the trash instead of the live ones
+	Deleted bool
+	// Owner selects the tasks of one owner, callers only ever see the tasks
+	// of other owners with the admin role
+	Owner string
+	// ListId selects the tasks of one list
+	ListId int
+}
+
+// TodoListResponse is one page of tasks. NextCursor is empty on the last page.
+type TodoListResponse struct {
+	Items      []TodoResponse `json:"items"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// SortField returns the field q is sorted by and whether the order is descending
+func (q TodoQuery) SortField() (string, bool) {
+	if q.Sort == "" {
+		return SortID, false
+	}
+	if strings.HasPrefix(q.Sort, "-") {
+		return q.Sort[1:], true
+	}
+	return q.Sort, false
+}
+
+// Normalize fills in the default page size
+func (q *TodoQuery) Normalize() {
+	if q.Limit == 0 {
+		q.Limit = DefaultLimit
+	}
+}
+
+// Validate checks the sort field, page size and cursor of q
+func (q TodoQuery) Validate() error {
+	var v Validator
+	field, _ := q.SortField()
+	v.Check("sort", OneOf(field, SortFields))
+	v.Check("limit", Between(q.Limit, 1, MaxLimit))
+	v.Check("list_id", Min(q.ListId, 0))
+	if q.Cursor != "" {
+		_, err := q.DecodeCursor()
+		v.Nest("", err)
+	}
+	return v.Err()
+}
+
+// Cursor is the position of the last task of a page in the sort order it was listed with
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	Id    int    `json:"id"`
+}
+
+// Time returns the cursor value of a timestamp sort field
+func (c Cursor) Time() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, c.Value)
+}
+
+// NextCursor returns the cursor of the page following last when sorted by q.Sort
+func (q TodoQuery) NextCursor(last TodoResponse) string {
+	field, _ := q.SortField()
+	cursor := Cursor{Sort: q.Sort, Id: last.Id}
+	switch field {
+	case SortName:
+		cursor.Value = last.Name
+	case SortCreatedAt:
+		cursor.Value = formatCursorTime(last.CreatedAt)
+	case SortUpdatedAt:
+		cursor.Value = formatCursorTime(last.UpdatedAt)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes q.Cursor, which must come from a query with the same sort
+func (q TodoQuery) DecodeCursor() (Cursor, error) {
+	var cursor Cursor
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil {
+		return Cursor{}, cursorError("is malformed")
+	}
+	if cursor.Sort != q.Sort {
+		return Cursor{}, cursorError("was issued for a different sort")
+	}
+	field, _ := q.SortField()
+	if field == SortCreatedAt || field == SortUpdatedAt {
+		if _, err := cursor.Time(); err != nil {
+			return Cursor{}, cursorError("is malformed")
+		}
+	}
+	return cursor, nil
+}
+
+// cursorError returns the validation error of an unusable cursor
+func cursorError(message string) error {
+	var v Validator
+	v.Add("cursor", message)
+	return v.Err()
+}
+
+func formatCursorTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,175 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Rule checks one field value and returns why it is invalid, or "" when it is valid
+type Rule func() string
+
+// FieldError is a field of a request and the rule it broke
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) String() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationError lists every invalid field of a request. It matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return ErrValidation.Error() + ": " + strings.Join(e.Messages(), "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Messages returns one message per invalid field, e.g. "name is required"
+func (e *ValidationError) Messages() []string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.String()
+	}
+	return messages
+}
+
+// Validator collects the field errors of a request
+type Validator struct {
+	fields []FieldError
+}
+
+// Check runs rules on field in order and records the first one failing
+func (v *Validator) Check(field string, rules ...Rule) {
+	for _, rule := range rules {
+		if message := rule(); message != "" {
+			v.Add(field, message)
+			return
+		}
+	}
+}
+
+// Add records that field is invalid
+func (v *Validator) Add(field, message string) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: message})
+}
+
+// Nest records the field errors of err, returned by validating a part of
+// the request, under prefix, e.g. operations[0].todo.name. An empty prefix
+// records them as they are.
+func (v *Validator) Nest(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		v.Add(prefix, strings.TrimPrefix(err.Error(), ErrValidation.Error()+": "))
+		return
+	}
+	for _, field := range validationErr.Fields {
+		if prefix != "" {
+			field.Field = prefix + "." + field.Field
+		}
+		v.Add(field.Field, field.Message)
+	}
+}
+
+// Err returns a *ValidationError listing every recorded field error, or nil
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+// Required fails on blank strings
+func Required(value string) Rule {
+	return func() string {
+		if strings.TrimSpace(value) == "" {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// MaxLength fails on strings longer than max characters
+func MaxLength(value string, max int) Rule {
+	return func() string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("must be at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// OneOf fails on strings other than values
+func OneOf(value string, values []string) Rule {
+	return func() string {
+		for _, v := range values {
+			if v == value {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+}
+
+// Min fails on numbers below min
+func Min(value, min int) Rule {
+	return func() string {
+		if value < min {
+			return fmt.Sprintf("must be at least %d", min)
+		}
+		return ""
+	}
+}
+
+// Between fails on numbers outside [min, max]
+func Between(value, min, max int) Rule {
+	return func() string {
+		if value < min || value > max {
+			return fmt.Sprintf("must be between %d and %d", min, max)
+		}
+		return ""
+	}
+}
+
+// MaxItems fails on lists of more than max items
+func MaxItems(count, max int) Rule {
+	return func() string {
+		if count > max {
+			return fmt.Sprintf("must hold at most %d items", max)
+		}
+		return ""
+	}
+}
+
+// TimeBetween fails on times outside [min, max), a nil time is valid
+func TimeBetween(value *time.Time, min, max time.Time) Rule {
+	return func() string {
+		if value != nil && (value.Before(min) || !value.Before(max)) {
+			return fmt.Sprintf("must be between %s and %s", min.Format(time.RFC3339), max.Format(time.RFC3339))
+		}
+		return ""
+	}
+}
+
+// Must fails with message unless ok
+func Must(ok bool, message string) Rule {
+	return func() string {
+		if !ok {
+			return message
+		}
+		return ""
+	}
+}
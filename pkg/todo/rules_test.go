@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRules(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{"required", Required("a"), ""},
+		{"required blank", Required(" "), "is required"},
+		{"max length", MaxLength("héllo", 5), ""},
+		{"max length exceeded", MaxLength("héllo!", 5), "must be at most 5 characters"},
+		{"one of", OneOf("b", []string{"a", "b"}), ""},
+		{"one of other", OneOf("c", []string{"a", "b"}), "must be one of a, b"},
+		{"min", Min(1, 1), ""},
+		{"min below", Min(0, 1), "must be at least 1"},
+		{"between", Between(5, 1, 5), ""},
+		{"between above", Between(6, 1, 5), "must be between 1 and 5"},
+		{"max items", MaxItems(2, 2), ""},
+		{"max items exceeded", MaxItems(3, 2), "must hold at most 2 items"},
+		{"time nil", TimeBetween(nil, MinDueAt, MaxDueAt), ""},
+		{"time in range", TimeBetween(&now, MinDueAt, MaxDueAt), ""},
+		{"time out of range", TimeBetween(&now, MinDueAt, now), "must be between 2000-01-01T00:00:00Z and 2024-05-01T00:00:00Z"},
+		{"must", Must(true, "is wrong"), ""},
+		{"must not", Must(false, "is wrong"), "is wrong"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule(); got != tt.want {
+				t.Errorf("rule() = %q, want %q\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidator(t *testing.T) {
+	var nested Validator
+	nested.Add("name", "is required")
+
+	var v Validator
+	v.Check("name", Required(""), MaxLength("", 1))
+	v.Check("limit", Between(10, 1, 5))
+	v.Check("mode", OneOf("atomic", []string{"atomic"}))
+	v.Nest("todo", nested.Err())
+	v.Nest("cursor", fmt.Errorf("%w: is malformed", ErrValidation))
+	v.Nest("ignored", nil)
+
+	err := v.Err()
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Err() = %v, want ErrValidation\n", err)
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Err() = %T, want *ValidationError\n", err)
+		return
+	}
+	want := []string{"name is required", "limit must be between 1 and 5", "todo.name is required", "cursor is malformed"}
+	if got := validationErr.Messages(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Messages() = %v, want %v\n", got, want)
+	}
+
+	var empty Validator
+	if err := empty.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil\n", err)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		wantErr        string
+		wantValidation bool
+	}{
+		{"valid", `{"name":"task","tags":["home"]}`, "", false},
+		{"unknown field", `{"name":"task","colour":"red"}`, "colour is not a known field", true},
+		{"wrong type", `{"name":"task","tags":"home"}`, "tags must be of type []string, got string", true},
+		{"malformed", `{"name":`, "invalid JSON", false},
+		{"empty", ``, "body is empty", false},
+		{"trailing data", `{"name":"task"} {}`, "single JSON value", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input TodoRequestInput
+			err := DecodeJSON([]byte(tt.data), &input)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("DecodeJSON() err = %v, want nil\n", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || errors.Is(err, ErrValidation) != tt.wantValidation {
+				t.Errorf("DecodeJSON() err = %v, want %q with validation %v\n", err, tt.wantErr, tt.wantValidation)
+			}
+		})
+	}
+}
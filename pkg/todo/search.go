@@ -0,0 +1,64 @@
+package todo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Page sizes and limits for TodoSearchQuery
+const (
+	DefaultSearchLimit   = 20
+	MaxSearchLimit       = 100
+	MaxSearchQueryLength = 200
+)
+
+// TodoSearchQuery is a full-text search over task names, descriptions and tags.
+// Every word of Q has to match, as a whole word or as the prefix of one.
+type TodoSearchQuery struct {
+	Q         string
+	Completed *bool
+	Limit     int
+}
+
+// TodoSearchResult is a task matching a search along with how well it matched.
+// Highlights wrap matched words in <mark> tags, the task text itself is not HTML escaped.
+type TodoSearchResult struct {
+	TodoResponse
+	Rank      float64       `json:"rank"`
+	Highlight TodoHighlight `json:"highlight"`
+}
+
+// TodoHighlight holds the matched fields of a task with their matches marked
+type TodoHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// TodoSearchResponse lists search results, best match first
+type TodoSearchResponse struct {
+	Items []TodoSearchResult `json:"items"`
+}
+
+// Normalize fills in the default page size
+func (q *TodoSearchQuery) Normalize() {
+	q.Q = strings.TrimSpace(q.Q)
+	if q.Limit == 0 {
+		q.Limit = DefaultSearchLimit
+	}
+}
+
+// Terms splits Q into the words a task has to match, anything other than
+// letters and digits separates words so search operators are never passed on
+func (q TodoSearchQuery) Terms() []string {
+	return strings.FieldsFunc(q.Q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Validate checks the search terms and page size of q
+func (q TodoSearchQuery) Validate() error {
+	var v Validator
+	v.Check("q", Required(q.Q), MaxLength(q.Q, MaxSearchQueryLength))
+	v.Check("limit", Between(q.Limit, 1, MaxSearchLimit))
+	return v.Err()
+}
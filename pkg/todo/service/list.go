@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+)
+
+// scope returns ctx carrying the scope of its principal extended with the
+// lists the principal is a member of, so store calls made with it see the
+// tasks of those lists, along with that scope
+func (s *Service) scope(ctx context.Context) (context.Context, store.Scope, error) {
+	scope := store.ScopeFromContext(ctx)
+	if scope.All || scope.Lists != nil {
+		return ctx, scope, nil
+	}
+	roles, err := s.store.ListRoles(ctx, scope.Owner)
+	if err != nil {
+		return ctx, scope, err
+	}
+	scope.Lists = roles
+	return store.NewContext(ctx, scope), scope, nil
+}
+
+// authorizeList checks that scope holds at least the min role in the list
+// with id. Lists the scope is not a member of are reported as not found,
+// like the tasks of other owners. Scopes seeing every task hold every role.
+func authorizeList(scope store.Scope, id int, min string) error {
+	if scope.All {
+		return nil
+	}
+	role := scope.Lists[id]
+	switch {
+	case role == "":
+		return fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	case !todo.ListRoleAtLeast(role, min):
+		return fmt.Errorf("%w: this takes the %s role in list %d, you are a %s", todo.ErrForbidden, min, id, role)
+	}
+	return nil
+}
+
+// checkTarget checks that scope may put the task of input in the list it
+// names, which takes the editor role. Leaving a task in the list it already
+// belongs to, or taking it out of its list, takes none.
+func (s *Service) checkTarget(ctx context.Context, scope store.Scope, input todo.TodoRequestInput) error {
+	if input.ListId == 0 {
+		return nil
+	}
+	if input.Id != 0 {
+		// A task that cannot be read is reported by the write itself
+		current, err := s.store.GetTodoTaskByID(ctx, []int{input.Id})
+		if errors.Is(err, todo.ErrNotFound) || (err == nil && current[0].ListId == input.ListId) {
+			return nil
+		}
+	}
+
+	err := authorizeList(scope, input.ListId, todo.ListRoleEditor)
+	if err == nil && scope.All {
+		_, err = s.store.GetList(ctx, input.ListId)
+	}
+	if errors.Is(err, todo.ErrNotFound) {
+		var v todo.Validator
+		v.Add("list_id", fmt.Sprintf("names no list, got %d", input.ListId))
+		return v.Err()
+	}
+	return err
+}
+
+// withRole sets the role of subject in list
+func withRole(list todo.ListResponse, subject string) todo.ListResponse {
+	list.Role = list.MemberRole(subject)
+	return list
+}
+
+// ListCreateRequest creates a list with the caller as its owner
+func (s *Service) ListCreateRequest(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	if err := input.Validate(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	if input.Id != 0 {
+		return todo.ListResponse{}, fmt.Errorf("%w: id is assigned by the server and must not be set", todo.ErrValidation)
+	}
+
+	owner := store.ScopeFromContext(ctx).Owner
+	response, err := s.store.CreateList(ctx, input, owner)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	logging.FromContext(ctx).Info("list created", "list_id", response.Id)
+	return withRole(response, owner), nil
+}
+
+// ListGetRequest returns the list with id, which the caller must be a member of
+func (s *Service) ListGetRequest(ctx context.Context, id int) (todo.ListResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.ListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := authorizeList(scope, id, todo.ListRoleViewer); err != nil {
+		return todo.ListResponse{}, err
+	}
+
+	response, err := s.store.GetList(ctx, id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	return withRole(response, scope.Owner), nil
+}
+
+// ListsGetRequest returns the lists the caller is a member of, or every
+// list for admins
+func (s *Service) ListsGetRequest(ctx context.Context) (todo.ListsResponse, error) {
+	scope := store.ScopeFromContext(ctx)
+	member := scope.Owner
+	if scope.All {
+		member = ""
+	}
+
+	lists, err := s.store.ListLists(ctx, member)
+	if err != nil {
+		return todo.ListsResponse{}, err
+	}
+	for i := range lists {
+		lists[i] = withRole(lists[i], scope.Owner)
+	}
+	return todo.ListsResponse{Items: lists}, nil
+}
+
+// ListUpdateRequest changes the name and description of a list, which
+// takes the editor role
+func (s *Service) ListUpdateRequest(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	if err := validateIDs([]int{input.Id}); err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := input.Validate(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := authorizeList(scope, input.Id, todo.ListRoleEditor); err != nil {
+		return todo.ListResponse{}, err
+	}
+
+	response, err := s.store.UpdateList(ctx, input)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	logging.FromContext(ctx).Info("list updated", "list_id", response.Id)
+	return withRole(response, scope.Owner), nil
+}
+
+// ListDeleteRequest deletes a list, which takes the owner role. Its tasks
+// stay with their owners.
+func (s *Service) ListDeleteRequest(ctx context.Context, id int) (todo.ListResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.ListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := authorizeList(scope, id, todo.ListRoleOwner); err != nil {
+		return todo.ListResponse{}, err
+	}
+
+	response, err := s.store.GetList(ctx, id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := s.store.DeleteList(ctx, id); err != nil {
+		return todo.ListResponse{}, err
+	}
+	logging.FromContext(ctx).Info("list deleted", "list_id", id)
+	response.Message = "Success"
+	return withRole(response, scope.Owner), nil
+}
+
+// ListMemberPutRequest adds a member to a list or changes its role, which
+// takes the owner role. A list always keeps at least one owner.
+func (s *Service) ListMemberPutRequest(ctx context.Context, id int, member todo.ListMember) (todo.ListResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := member.Validate(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if err := authorizeList(scope, id, todo.ListRoleOwner); err != nil {
+		return todo.ListResponse{}, err
+	}
+
+	if err := s.store.PutListMember(ctx, id, member); err != nil {
+		return todo.ListResponse{}, err
+	}
+	logging.FromContext(ctx).Info("list member set", "list_id", id, "member", member.Subject, "role", member.Role)
+
+	response, err := s.store.GetList(ctx, id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	response.Message = "Success"
+	return withRole(response, scope.Owner), nil
+}
+
+// ListMemberDeleteRequest removes a member from a list, which takes the
+// owner role unless members remove themselves. The last owner of a list
+// cannot be removed.
+func (s *Service) ListMemberDeleteRequest(ctx context.Context, id int, subject string) (todo.ListResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.ListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	min := todo.ListRoleOwner
+	if subject == scope.Owner {
+		min = todo.ListRoleViewer
+	}
+	if err := authorizeList(scope, id, min); err != nil {
+		return todo.ListResponse{}, err
+	}
+
+	if err := s.store.DeleteListMember(ctx, id, subject); err != nil {
+		return todo.ListResponse{}, err
+	}
+	logging.FromContext(ctx).Info("list member removed", "list_id", id, "member", subject)
+
+	response, err := s.store.GetList(ctx, id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	response.Message = "Success"
+	return withRole(response, scope.Owner), nil
+}
+
+// ListTodosRequest returns the tasks of the list with id matching query,
+// which the caller must be a member of, like TodoGetRequest
+func (s *Service) ListTodosRequest(ctx context.Context, id int, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	if err := authorizeList(scope, id, todo.ListRoleViewer); err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	if scope.All {
+		if _, err := s.store.GetList(ctx, id); err != nil {
+			return todo.TodoListResponse{}, err
+		}
+	}
+
+	query.ListId = id
+	return s.TodoGetRequest(ctx, query)
+}
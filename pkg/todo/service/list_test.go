@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/memory"
+	"testing"
+)
+
+func TestService_ListPermissions(t *testing.T) {
+	s := New(memory.New())
+	as := func(subject string, roles ...string) context.Context {
+		return auth.NewContext(context.Background(), auth.Principal{Subject: subject, Roles: roles})
+	}
+	alice, bob, carol, dave, admin := as("alice"), as("bob"), as("carol"), as("dave"), as("root", auth.RoleAdmin)
+
+	list, err := s.ListCreateRequest(alice, todo.ListRequestInput{Name: "home"})
+	if err != nil || list.Role != todo.ListRoleOwner {
+		t.Fatalf("ListCreateRequest() = %+v, %v, want a list owned by alice\n", list, err)
+	}
+	for _, member := range []todo.ListMember{{Subject: "bob", Role: todo.ListRoleEditor}, {Subject: "carol", Role: todo.ListRoleViewer}} {
+		if _, err := s.ListMemberPutRequest(alice, list.Id, member); err != nil {
+			t.Fatalf("ListMemberPutRequest() err = %v\n", err)
+		}
+	}
+	task, err := s.TodoCreateRequest(bob, todo.TodoRequestInput{Name: "buy milk", ListId: list.Id})
+	if err != nil {
+		t.Fatalf("TodoCreateRequest() err = %v\n", err)
+	}
+	update := task.RequestInput()
+	update.Name = "buy oat milk"
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+	}{
+		{"viewer reads the list", func() error {
+			got, err := s.ListGetRequest(carol, list.Id)
+			if err == nil && got.Role != todo.ListRoleViewer {
+				t.Errorf("ListGetRequest() role = %v, want %v\n", got.Role, todo.ListRoleViewer)
+			}
+			return err
+		}, nil},
+		{"viewer lists the tasks", func() error {
+			got, err := s.ListTodosRequest(carol, list.Id, todo.TodoQuery{})
+			if err == nil && len(got.Items) != 1 {
+				t.Errorf("ListTodosRequest() = %+v, want the task of bob\n", got.Items)
+			}
+			return err
+		}, nil},
+		{"viewer renames the list", func() error {
+			_, err := s.ListUpdateRequest(carol, todo.ListRequestInput{Id: list.Id, Name: "mine"})
+			return err
+		}, todo.ErrForbidden},
+		{"viewer adds a member", func() error {
+			_, err := s.ListMemberPutRequest(carol, list.Id, todo.ListMember{Subject: "dave", Role: todo.ListRoleViewer})
+			return err
+		}, todo.ErrForbidden},
+		{"viewer adds a task", func() error {
+			_, err := s.TodoCreateRequest(carol, todo.TodoRequestInput{Name: "a", ListId: list.Id})
+			return err
+		}, todo.ErrForbidden},
+		{"viewer changes a task", func() error {
+			_, err := s.TodoUpdateRequest(carol, update)
+			return err
+		}, todo.ErrForbidden},
+		{"viewer adds a task in a batch", func() error {
+			_, err := s.TodoBatchRequest(carol, todo.TodoBatchRequest{Operations: []todo.TodoBatchOperation{
+				{Op: todo.OpCreate, Todo: &todo.TodoRequestInput{Name: "a", ListId: list.Id}},
+			}})
+			return err
+		}, todo.ErrForbidden},
+		{"editor renames the list", func() error {
+			_, err := s.ListUpdateRequest(bob, todo.ListRequestInput{Id: list.Id, Name: "house"})
+			return err
+		}, nil},
+		{"editor removes a member", func() error {
+			_, err := s.ListMemberDeleteRequest(bob, list.Id, "carol")
+			return err
+		}, todo.ErrForbidden},
+		{"editor deletes the list", func() error {
+			_, err := s.ListDeleteRequest(bob, list.Id)
+			return err
+		}, todo.ErrForbidden},
+		{"owner changes the task of an editor", func() error {
+			_, err := s.TodoPatchRequest(alice, task.Id, []byte(`{"completed":true}`), 0)
+			return err
+		}, nil},
+		{"non member reads the list", func() error {
+			_, err := s.ListGetRequest(dave, list.Id)
+			return err
+		}, todo.ErrNotFound},
+		{"non member lists the tasks", func() error {
+			_, err := s.ListTodosRequest(dave, list.Id, todo.TodoQuery{})
+			return err
+		}, todo.ErrNotFound},
+		{"non member adds a task", func() error {
+			_, err := s.TodoCreateRequest(dave, todo.TodoRequestInput{Name: "a", ListId: list.Id})
+			return err
+		}, todo.ErrValidation},
+		{"non member changes a task", func() error {
+			_, err := s.TodoUpdateRequest(dave, update)
+			return err
+		}, todo.ErrNotFound},
+		{"admin adds a task to a missing list", func() error {
+			_, err := s.TodoCreateRequest(admin, todo.TodoRequestInput{Name: "a", ListId: 99})
+			return err
+		}, todo.ErrValidation},
+		{"admin lists the tasks of a missing list", func() error {
+			_, err := s.ListTodosRequest(admin, 99, todo.TodoQuery{})
+			return err
+		}, todo.ErrNotFound},
+		{"last owner steps down", func() error {
+			_, err := s.ListMemberPutRequest(alice, list.Id, todo.ListMember{Subject: "alice", Role: todo.ListRoleEditor})
+			return err
+		}, todo.ErrConflict},
+		{"last owner leaves", func() error {
+			_, err := s.ListMemberDeleteRequest(alice, list.Id, "alice")
+			return err
+		}, todo.ErrConflict},
+		{"viewer leaves", func() error {
+			_, err := s.ListMemberDeleteRequest(carol, list.Id, "carol")
+			return err
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Errorf("%s err = %v, want %v\n", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+
+	lists := []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{{"former member", carol, 0}, {"member", bob, 1}, {"admin", admin, 1}}
+	for _, tt := range lists {
+		if got, err := s.ListsGetRequest(tt.ctx); err != nil || len(got.Items) != tt.want {
+			t.Errorf("ListsGetRequest() %s = %+v, %v, want %d lists\n", tt.name, got.Items, err, tt.want)
+		}
+	}
+
+	// The tasks of a deleted list stay with their owners
+	if _, err := s.ListDeleteRequest(alice, list.Id); err != nil {
+		t.Fatalf("ListDeleteRequest() err = %v\n", err)
+	}
+	got, err := s.TodoGetRequest(bob, todo.TodoQuery{Ids: []int{task.Id}})
+	if err != nil || len(got.Items) != 1 || got.Items[0].ListId != 0 {
+		t.Errorf("TodoGetRequest() = %+v, %v, want the task of bob in no list\n", got.Items, err)
+	}
+	if got, err := s.TodoGetRequest(alice, todo.TodoQuery{Ids: []int{task.Id}}); err != nil || len(got.Items) != 0 {
+		t.Errorf("TodoGetRequest() of alice = %+v, %v, want none\n", got.Items, err)
+	}
+}
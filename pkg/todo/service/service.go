@@ -2,23 +2,55 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/jsonutil"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
-	"github.com/RanbirSingh-Velotio/todo-service/store/sqlite"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"time"
 )
 
 type Service struct {
-	store *sqlite.StoreSvc
+	store store.StoreSvc
 }
 
-func New(store *sqlite.StoreSvc) *Service {
+func New(store store.StoreSvc) *Service {
 	service := &Service{
 		store: store,
 	}
 	return service
 }
 
+// validateIDs rejects non-positive task ids
+func validateIDs(ids []int) error {
+	for _, id := range ids {
+		if id <= 0 {
+			return fmt.Errorf("%w: id must be a positive integer, got %d", todo.ErrValidation, id)
+		}
+	}
+	return nil
+}
+
 func (s *Service) TodoCreateRequest(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
-	chErr := make(chan error)
+	requestInput.Normalize()
+	if err := requestInput.Validate(); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	if requestInput.Id != 0 {
+		// Ids are assigned by the store, accepting one from the client would let it collide
+		return todo.TodoResponse{}, fmt.Errorf("%w: id is assigned by the server and must not be set", todo.ErrValidation)
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	if err := s.checkTarget(ctx, scope, requestInput); err != nil {
+		return todo.TodoResponse{}, err
+	}
+
+	chErr := make(chan error, 1)
 	var response todo.TodoResponse
 	go func() {
 		r, err := s.store.CreateTodoTask(ctx, requestInput)
@@ -28,35 +60,229 @@ func (s *Service) TodoCreateRequest(ctx context.Context, requestInput todo.TodoR
 
 	select {
 	case <-ctx.Done():
-		return response, ctx.Err()
+		return todo.TodoResponse{}, ctx.Err()
 	case err := <-chErr:
+		if err == nil {
+			logging.FromContext(ctx).Info("task created", "id", response.Id)
+		}
 		return response, err
 	}
 }
 
-func (s *Service) TodoGetRequest(ctx context.Context, ids []int) []todo.TodoResponse {
-	chErr := make(chan error)
-	var response []todo.TodoResponse
+func (s *Service) TodoGetRequest(ctx context.Context, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	if err := validateIDs(query.Ids); err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	query.Normalize()
+	if err := query.Validate(); err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	ctx, _, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoListResponse{}, err
+	}
+
+	chErr := make(chan error, 1)
+	var response todo.TodoListResponse
 	go func() {
-		r := s.store.GetTodoTaskByID(ctx, ids)
+		r, err := s.store.ListTodoTasks(ctx, query)
 		response = r
-		chErr <- nil
+		chErr <- err
 	}()
 	select {
 	case <-ctx.Done():
-		return response
-	case _ = <-chErr:
-		return response
+		return todo.TodoListResponse{}, ctx.Err()
+	case err := <-chErr:
+		if err == nil {
+			logging.FromContext(ctx).Debug("tasks listed", "count", len(response.Items), "next_cursor", response.NextCursor != "")
+		}
+		return response, err
+	}
+}
+
+// TodoDeleteRequest moves the tasks with the given ids to the trash. When
+// version is not 0 a single id must be given and the task must be at version.
+func (s *Service) TodoDeleteRequest(ctx context.Context, ids []int, version int) (todo.TodoDeleteResponse, error) {
+	if len(ids) == 0 {
+		return todo.TodoDeleteResponse{}, fmt.Errorf("%w: ids is required", todo.ErrValidation)
 	}
+	if err := validateIDs(ids); err != nil {
+		return todo.TodoDeleteResponse{}, err
+	}
+	if version != 0 && len(ids) != 1 {
+		return todo.TodoDeleteResponse{}, fmt.Errorf("%w: a version can only be given when deleting a single task", todo.ErrValidation)
+	}
+	ctx, _, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoDeleteResponse{}, err
+	}
+
+	response, err := s.store.DeleteTodoTaskByID(ctx, ids, version)
+	if err != nil {
+		return todo.TodoDeleteResponse{}, err
+	}
+	logging.FromContext(ctx).Info("tasks deleted", "ids", response.Deleted, "not_found", response.NotFound)
+	return response, nil
 }
-func (s *Service) TodoDeleteRequest(ctx context.Context, ids []int) todo.TodoResponse {
 
-	response := s.store.DeleteTodoTaskByID(ctx, ids)
-	return response
+// TodoRestoreRequest moves the task with the given id out of the trash
+func (s *Service) TodoRestoreRequest(ctx context.Context, id int) (todo.TodoResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	ctx, _, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+
+	response, err := s.store.RestoreTodoTaskByID(ctx, id)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	logging.FromContext(ctx).Info("task restored", "id", id)
+	return response, nil
 }
-func (s *Service) TodoUpdateRequest(ctx context.Context, requestInput todo.TodoRequestInput) todo.TodoResponse {
 
-	response := s.store.UpdateTodoTaskByID(ctx, requestInput)
-	return response
+// TodoPurgeRequest permanently removes the tasks moved to the trash before deletedBefore
+func (s *Service) TodoPurgeRequest(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged, err := s.store.PurgeTodoTasks(ctx, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		logging.FromContext(ctx).Info("tasks purged from the trash", "count", purged, "deleted_before", deletedBefore)
+	}
+	return purged, nil
+}
+func (s *Service) TodoUpdateRequest(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	if err := validateIDs([]int{requestInput.Id}); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	requestInput.Normalize()
+	if err := requestInput.Validate(); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	if err := s.checkTarget(ctx, scope, requestInput); err != nil {
+		return todo.TodoResponse{}, err
+	}
 
+	response, err := s.store.UpdateTodoTaskByID(ctx, requestInput)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	logging.FromContext(ctx).Info("task updated", "id", response.Id)
+	return response, nil
+}
+
+// TodoPatchRequest applies an RFC 7396 merge patch to the task with the given id,
+// so only the fields present in patch are changed. The task is updated only
+// if no other write changed it since it was read, and, when version is not
+// 0, if it is at version.
+func (s *Service) TodoPatchRequest(ctx context.Context, id int, patch []byte, version int) (todo.TodoResponse, error) {
+	if err := validateIDs([]int{id}); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	ctx, _, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+
+	current, err := s.store.GetTodoTaskByID(ctx, []int{id})
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	if version != 0 && current[0].Version != version {
+		return todo.TodoResponse{}, fmt.Errorf("%w: task %d is at version %d, not %d", todo.ErrPreconditionFailed, id, current[0].Version, version)
+	}
+
+	target, err := json.Marshal(current[0].RequestInput())
+	if err != nil {
+		return todo.TodoResponse{}, fmt.Errorf("%w: %v", todo.ErrInternal, err)
+	}
+	patched, err := jsonutil.MergePatch(target, patch)
+	if err != nil {
+		return todo.TodoResponse{}, fmt.Errorf("%w: invalid merge patch: %v", todo.ErrValidation, err)
+	}
+
+	var requestInput todo.TodoRequestInput
+	if err := todo.DecodeJSON(patched, &requestInput); err != nil {
+		if errors.Is(err, todo.ErrValidation) {
+			return todo.TodoResponse{}, err
+		}
+		return todo.TodoResponse{}, fmt.Errorf("%w: invalid merge patch: %v", todo.ErrValidation, err)
+	}
+	if requestInput.Id != id {
+		return todo.TodoResponse{}, fmt.Errorf("%w: id cannot be changed", todo.ErrValidation)
+	}
+
+	return s.TodoUpdateRequest(ctx, requestInput)
+}
+
+func (s *Service) TodoSearchRequest(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error) {
+	query.Normalize()
+	if err := query.Validate(); err != nil {
+		return todo.TodoSearchResponse{}, err
+	}
+	ctx, _, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoSearchResponse{}, err
+	}
+
+	chErr := make(chan error, 1)
+	var response todo.TodoSearchResponse
+	go func() {
+		r, err := s.store.SearchTodoTasks(ctx, query)
+		response = r
+		chErr <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return todo.TodoSearchResponse{}, ctx.Err()
+	case err := <-chErr:
+		if err == nil {
+			logging.FromContext(ctx).Debug("tasks searched", "count", len(response.Items))
+		}
+		return response, err
+	}
+}
+
+// TodoBatchRequest applies the create, update and delete operations of
+// request in one transaction. A batch with an invalid operation, or one
+// putting a task in a list the caller may not add tasks to, is rejected
+// before any operation is applied.
+func (s *Service) TodoBatchRequest(ctx context.Context, request todo.TodoBatchRequest) (todo.TodoBatchResponse, error) {
+	request.Normalize()
+	if err := request.Validate(); err != nil {
+		return todo.TodoBatchResponse{}, err
+	}
+	ctx, scope, err := s.scope(ctx)
+	if err != nil {
+		return todo.TodoBatchResponse{}, err
+	}
+	var v todo.Validator
+	for i, op := range request.Operations {
+		if op.Todo == nil {
+			continue
+		}
+		err := s.checkTarget(ctx, scope, *op.Todo)
+		if err != nil && !errors.Is(err, todo.ErrValidation) {
+			return todo.TodoBatchResponse{}, err
+		}
+		v.Nest(fmt.Sprintf("operations[%d].todo", i), err)
+	}
+	if err := v.Err(); err != nil {
+		return todo.TodoBatchResponse{}, err
+	}
+
+	response, err := s.store.BatchTodoTasks(ctx, request)
+	if err != nil {
+		return todo.TodoBatchResponse{}, err
+	}
+	logging.FromContext(ctx).Info("batch applied", "mode", response.Mode, "operations", len(response.Results),
+		"failed", response.Failed(), "committed", response.Committed)
+	return response, nil
 }
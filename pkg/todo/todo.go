@@ -2,27 +2,124 @@ package todo
 
 import (
 	"context"
+	"sort"
+	"time"
 )
 
+// Priority levels a task can be given, PriorityMedium is the default
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities lists every accepted priority from lowest to highest
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent}
+
 type TodoRequestInput struct {
-	Id        int    `json:"id"`
-	Name      string `json:"name"`
-	Completed bool   `json:"completed"`
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    string     `json:"priority"`
+	Tags        []string   `json:"tags"`
+	// ListId is the list the task belongs to, 0 for none
+	ListId int `json:"list_id,omitempty"`
+	// Version, when set, is the version the task must be at to be updated
+	Version int `json:"version,omitempty"`
 }
 
 type TodoResponse struct {
-	Message   string `json:"message"`
-	Id        int    `json:"id,omitempty"`
-	Name      string `json:"name,omitempty"`
-	Completed bool   `json:"completed,omitempty"`
+	Message     string     `json:"message"`
+	Id          int        `json:"id,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Completed   bool       `json:"completed,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// DeletedAt is set on tasks in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version is incremented on every write of the task
+	Version int `json:"version,omitempty"`
+	// OwnerId is the subject of the principal that created the task
+	OwnerId string `json:"owner_id,omitempty"`
+	// ListId is the list the task belongs to, 0 for none
+	ListId int `json:"list_id,omitempty"`
+}
+
+// TodoDeleteResponse reports which of the requested ids were moved to the
+// trash and which matched no live task
+type TodoDeleteResponse struct {
+	Message  string `json:"message"`
+	Deleted  []int  `json:"deleted"`
+	NotFound []int  `json:"not_found"`
+}
+
+// TodoCounts is the number of stored tasks by completion state
+type TodoCounts struct {
+	Completed int `json:"completed"`
+	Open      int `json:"open"`
+}
+
+// NewTodoDeleteResponse reports the ids among requested that are not in deleted as not found
+func NewTodoDeleteResponse(requested, deleted []int) TodoDeleteResponse {
+	response := TodoDeleteResponse{Message: "Success", Deleted: []int{}, NotFound: []int{}}
+	seen := map[int]bool{}
+	for _, id := range deleted {
+		seen[id] = true
+	}
+	response.Deleted = append(response.Deleted, deleted...)
+	sort.Ints(response.Deleted)
+	for _, id := range requested {
+		if !seen[id] {
+			seen[id] = true
+			response.NotFound = append(response.NotFound, id)
+		}
+	}
+	return response
+}
+
+// RequestInput returns the input that would recreate t, used as the
+// target document when applying a merge patch
+func (t TodoResponse) RequestInput() TodoRequestInput {
+	return TodoRequestInput{
+		Id:          t.Id,
+		Name:        t.Name,
+		Description: t.Description,
+		Completed:   t.Completed,
+		DueAt:       t.DueAt,
+		Priority:    t.Priority,
+		Tags:        t.Tags,
+		ListId:      t.ListId,
+		Version:     t.Version,
+	}
 }
 
 //go:generate mockgen -destination mockservice/mock_service.go -package mockservice github.com/RanbirSingh-Velotio/todo-service/pkg/todo Service
 type Service interface {
 	TodoCreateRequest(ctx context.Context, requestInput TodoRequestInput) (TodoResponse, error)
-	TodoGetRequest(ctx context.Context, ids []int) []TodoResponse
-	TodoDeleteRequest(ctx context.Context, ids []int) TodoResponse
-	TodoUpdateRequest(ctx context.Context, input TodoRequestInput) TodoResponse
+	TodoGetRequest(ctx context.Context, query TodoQuery) (TodoListResponse, error)
+	TodoDeleteRequest(ctx context.Context, ids []int, version int) (TodoDeleteResponse, error)
+	TodoRestoreRequest(ctx context.Context, id int) (TodoResponse, error)
+	TodoPurgeRequest(ctx context.Context, deletedBefore time.Time) (int, error)
+	TodoUpdateRequest(ctx context.Context, input TodoRequestInput) (TodoResponse, error)
+	TodoPatchRequest(ctx context.Context, id int, patch []byte, version int) (TodoResponse, error)
+	TodoSearchRequest(ctx context.Context, query TodoSearchQuery) (TodoSearchResponse, error)
+	TodoBatchRequest(ctx context.Context, request TodoBatchRequest) (TodoBatchResponse, error)
+	ListCreateRequest(ctx context.Context, input ListRequestInput) (ListResponse, error)
+	ListGetRequest(ctx context.Context, id int) (ListResponse, error)
+	ListsGetRequest(ctx context.Context) (ListsResponse, error)
+	ListUpdateRequest(ctx context.Context, input ListRequestInput) (ListResponse, error)
+	ListDeleteRequest(ctx context.Context, id int) (ListResponse, error)
+	ListMemberPutRequest(ctx context.Context, id int, member ListMember) (ListResponse, error)
+	ListMemberDeleteRequest(ctx context.Context, id int, subject string) (ListResponse, error)
+	ListTodosRequest(ctx context.Context, id int, query TodoQuery) (TodoListResponse, error)
 }
 
 var defaultService Service
@@ -0,0 +1,58 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Limits enforced on task fields
+const (
+	MaxNameLength        = 255
+	MaxDescriptionLength = 4000
+	MaxTags              = 20
+	MaxTagLength         = 50
+)
+
+// Range a due date has to fall in
+var (
+	MinDueAt = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	MaxDueAt = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Normalize fills defaults and trims tags so equal inputs are stored the same way
+func (in *TodoRequestInput) Normalize() {
+	if in.Priority == "" {
+		in.Priority = PriorityMedium
+	}
+	in.Priority = strings.ToLower(in.Priority)
+
+	tags := make([]string, 0, len(in.Tags))
+	seen := map[string]bool{}
+	for _, tag := range in.Tags {
+		tag = strings.TrimSpace(tag)
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	in.Tags = tags
+}
+
+// Validate checks in against the task field limits and reports every invalid field
+func (in TodoRequestInput) Validate() error {
+	var v Validator
+	v.Check("id", Min(in.Id, 0))
+	v.Check("name", Required(in.Name), MaxLength(in.Name, MaxNameLength))
+	v.Check("description", MaxLength(in.Description, MaxDescriptionLength))
+	v.Check("due_at", TimeBetween(in.DueAt, MinDueAt, MaxDueAt))
+	v.Check("priority", OneOf(in.Priority, Priorities))
+	v.Check("tags", MaxItems(len(in.Tags), MaxTags))
+	for i, tag := range in.Tags {
+		v.Check(fmt.Sprintf("tags[%d]", i), Required(tag), MaxLength(tag, MaxTagLength))
+	}
+	v.Check("list_id", Min(in.ListId, 0))
+	v.Check("version", Min(in.Version, 0))
+	return v.Err()
+}
@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodoRequestInput_Validate(t *testing.T) {
+	before := MinDueAt.Add(-time.Second)
+	tests := []struct {
+		name    string
+		input   TodoRequestInput
+		wantErr bool
+	}{
+		{"valid", TodoRequestInput{Name: "task", Priority: PriorityHigh, Tags: []string{"home"}}, false},
+		{"missing name", TodoRequestInput{Name: "  ", Priority: PriorityLow}, true},
+		{"long name", TodoRequestInput{Name: strings.Repeat("a", MaxNameLength+1), Priority: PriorityLow}, true},
+		{"long description", TodoRequestInput{Name: "task", Description: strings.Repeat("a", MaxDescriptionLength+1), Priority: PriorityLow}, true},
+		{"unknown priority", TodoRequestInput{Name: "task", Priority: "someday"}, true},
+		{"empty tag", TodoRequestInput{Name: "task", Priority: PriorityLow, Tags: []string{""}}, true},
+		{"long tag", TodoRequestInput{Name: "task", Priority: PriorityLow, Tags: []string{strings.Repeat("a", MaxTagLength+1)}}, true},
+		{"negative id", TodoRequestInput{Id: -1, Name: "task", Priority: PriorityLow}, true},
+		{"due before range", TodoRequestInput{Name: "task", Priority: PriorityLow, DueAt: &before}, true},
+		{"due at end of range", TodoRequestInput{Name: "task", Priority: PriorityLow, DueAt: &MaxDueAt}, true},
+		{"due in range", TodoRequestInput{Name: "task", Priority: PriorityLow, DueAt: &MinDueAt}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() err = %v, wantErr %v\n", err, tt.wantErr)
+				return
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("Validate() err = %v, want ErrValidation\n", err)
+			}
+		})
+	}
+}
+
+func TestTodoRequestInput_Validate_EveryField(t *testing.T) {
+	input := TodoRequestInput{Id: -1, Priority: "someday", Tags: []string{"home", ""}}
+	var validationErr *ValidationError
+	if err := input.Validate(); !errors.As(err, &validationErr) {
+		t.Errorf("Validate() err = %v, want *ValidationError\n", err)
+		return
+	}
+	want := []string{"id", "name", "priority", "tags[1]"}
+	var got []string
+	for _, field := range validationErr.Fields {
+		got = append(got, field.Field)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Validate() fields = %v, want %v\n", got, want)
+	}
+}
+
+func TestTodoRequestInput_Normalize(t *testing.T) {
+	input := TodoRequestInput{Name: "task", Tags: []string{" home ", "home", "work"}}
+	input.Normalize()
+	if input.Priority != PriorityMedium {
+		t.Errorf("Normalize() priority = %v, want %v\n", input.Priority, PriorityMedium)
+	}
+	if strings.Join(input.Tags, ",") != "home,work" {
+		t.Errorf("Normalize() tags = %v, want [home work]\n", input.Tags)
+	}
+}
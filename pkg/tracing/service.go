@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// tracedService starts a span for every call to the wrapped service
+type tracedService struct {
+	service todo.Service
+}
+
+// InstrumentService returns service with a span started for each of its calls
+func InstrumentService(service todo.Service) todo.Service {
+	return &tracedService{service: service}
+}
+
+func (s *tracedService) TodoCreateRequest(ctx context.Context, requestInput todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoCreateRequest")
+	defer func() { End(span, err) }()
+	response, err = s.service.TodoCreateRequest(ctx, requestInput)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.id", response.Id))
+	}
+	return response, err
+}
+
+func (s *tracedService) TodoGetRequest(ctx context.Context, query todo.TodoQuery) (response todo.TodoListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoGetRequest")
+	defer func() { End(span, err) }()
+	response, err = s.service.TodoGetRequest(ctx, query)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	}
+	return response, err
+}
+
+func (s *tracedService) TodoDeleteRequest(ctx context.Context, ids []int, version int) (response todo.TodoDeleteResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoDeleteRequest", attribute.IntSlice("todo.ids", ids))
+	defer func() { End(span, err) }()
+	return s.service.TodoDeleteRequest(ctx, ids, version)
+}
+
+func (s *tracedService) TodoRestoreRequest(ctx context.Context, id int) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoRestoreRequest", attribute.Int("todo.id", id))
+	defer func() { End(span, err) }()
+	return s.service.TodoRestoreRequest(ctx, id)
+}
+
+func (s *tracedService) TodoPurgeRequest(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoPurgeRequest")
+	defer func() { End(span, err) }()
+	purged, err = s.service.TodoPurgeRequest(ctx, deletedBefore)
+	span.SetAttributes(attribute.Int("todo.count", purged))
+	return purged, err
+}
+
+func (s *tracedService) TodoUpdateRequest(ctx context.Context, input todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoUpdateRequest", attribute.Int("todo.id", input.Id))
+	defer func() { End(span, err) }()
+	return s.service.TodoUpdateRequest(ctx, input)
+}
+
+func (s *tracedService) TodoPatchRequest(ctx context.Context, id int, patch []byte, version int) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoPatchRequest", attribute.Int("todo.id", id))
+	defer func() { End(span, err) }()
+	return s.service.TodoPatchRequest(ctx, id, patch, version)
+}
+
+func (s *tracedService) TodoSearchRequest(ctx context.Context, query todo.TodoSearchQuery) (response todo.TodoSearchResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoSearchRequest")
+	defer func() { End(span, err) }()
+	response, err = s.service.TodoSearchRequest(ctx, query)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	}
+	return response, err
+}
+
+func (s *tracedService) TodoBatchRequest(ctx context.Context, request todo.TodoBatchRequest) (response todo.TodoBatchResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/TodoBatchRequest", attribute.Int("todo.count", len(request.Operations)))
+	defer func() { End(span, err) }()
+	response, err = s.service.TodoBatchRequest(ctx, request)
+	span.SetAttributes(attribute.Int("todo.batch.failed", response.Failed()))
+	return response, err
+}
+
+func (s *tracedService) ListCreateRequest(ctx context.Context, input todo.ListRequestInput) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListCreateRequest")
+	defer func() { End(span, err) }()
+	response, err = s.service.ListCreateRequest(ctx, input)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.list.id", response.Id))
+	}
+	return response, err
+}
+
+func (s *tracedService) ListGetRequest(ctx context.Context, id int) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListGetRequest", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.service.ListGetRequest(ctx, id)
+}
+
+func (s *tracedService) ListsGetRequest(ctx context.Context) (response todo.ListsResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListsGetRequest")
+	defer func() { End(span, err) }()
+	response, err = s.service.ListsGetRequest(ctx)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	}
+	return response, err
+}
+
+func (s *tracedService) ListUpdateRequest(ctx context.Context, input todo.ListRequestInput) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListUpdateRequest", attribute.Int("todo.list.id", input.Id))
+	defer func() { End(span, err) }()
+	return s.service.ListUpdateRequest(ctx, input)
+}
+
+func (s *tracedService) ListDeleteRequest(ctx context.Context, id int) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListDeleteRequest", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.service.ListDeleteRequest(ctx, id)
+}
+
+func (s *tracedService) ListMemberPutRequest(ctx context.Context, id int, member todo.ListMember) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListMemberPutRequest", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.service.ListMemberPutRequest(ctx, id, member)
+}
+
+func (s *tracedService) ListMemberDeleteRequest(ctx context.Context, id int, subject string) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListMemberDeleteRequest", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.service.ListMemberDeleteRequest(ctx, id, subject)
+}
+
+func (s *tracedService) ListTodosRequest(ctx context.Context, id int, query todo.TodoQuery) (response todo.TodoListResponse, err error) {
+	ctx, span := Start(ctx, "todo.Service/ListTodosRequest", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	response, err = s.service.ListTodosRequest(ctx, id, query)
+	if err == nil {
+		span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	}
+	return response, err
+}
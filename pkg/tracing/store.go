@@ -0,0 +1,145 @@
+package tracing
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"go.opentelemetry.io/otel/attribute"
+	"time"
+)
+
+// tracedStore starts a span for every call to the wrapped store, the SQL
+// stores add a child span for each statement they run
+type tracedStore struct {
+	store store.StoreSvc
+}
+
+// InstrumentStore returns st with a span started for each of its calls
+func InstrumentStore(st store.StoreSvc) store.StoreSvc {
+	return &tracedStore{store: st}
+}
+
+func (s *tracedStore) ListTodoTasks(ctx context.Context, query todo.TodoQuery) (response todo.TodoListResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/ListTodoTasks")
+	defer func() { End(span, err) }()
+	response, err = s.store.ListTodoTasks(ctx, query)
+	span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	return response, err
+}
+
+func (s *tracedStore) CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/CreateTodoTask")
+	defer func() { End(span, err) }()
+	return s.store.CreateTodoTask(ctx, requestInput)
+}
+
+func (s *tracedStore) GetTodoTaskByID(ctx context.Context, id []int) (todos []todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/GetTodoTaskByID", attribute.IntSlice("todo.ids", id))
+	defer func() { End(span, err) }()
+	todos, err = s.store.GetTodoTaskByID(ctx, id)
+	span.SetAttributes(attribute.Int("todo.count", len(todos)))
+	return todos, err
+}
+
+func (s *tracedStore) DeleteTodoTaskByID(ctx context.Context, id []int, version int) (response todo.TodoDeleteResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/DeleteTodoTaskByID", attribute.IntSlice("todo.ids", id))
+	defer func() { End(span, err) }()
+	response, err = s.store.DeleteTodoTaskByID(ctx, id, version)
+	span.SetAttributes(attribute.Int("todo.count", len(response.Deleted)))
+	return response, err
+}
+
+func (s *tracedStore) RestoreTodoTaskByID(ctx context.Context, id int) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/RestoreTodoTaskByID", attribute.Int("todo.id", id))
+	defer func() { End(span, err) }()
+	return s.store.RestoreTodoTaskByID(ctx, id)
+}
+
+func (s *tracedStore) PurgeTodoTasks(ctx context.Context, deletedBefore time.Time) (purged int, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/PurgeTodoTasks")
+	defer func() { End(span, err) }()
+	purged, err = s.store.PurgeTodoTasks(ctx, deletedBefore)
+	span.SetAttributes(attribute.Int("todo.count", purged))
+	return purged, err
+}
+
+func (s *tracedStore) UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (response todo.TodoResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/UpdateTodoTaskByID", attribute.Int("todo.id", requestInput.Id))
+	defer func() { End(span, err) }()
+	return s.store.UpdateTodoTaskByID(ctx, requestInput)
+}
+
+func (s *tracedStore) SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (response todo.TodoSearchResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/SearchTodoTasks")
+	defer func() { End(span, err) }()
+	response, err = s.store.SearchTodoTasks(ctx, query)
+	span.SetAttributes(attribute.Int("todo.count", len(response.Items)))
+	return response, err
+}
+
+func (s *tracedStore) CountTodoTasks(ctx context.Context) (counts todo.TodoCounts, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/CountTodoTasks")
+	defer func() { End(span, err) }()
+	return s.store.CountTodoTasks(ctx)
+}
+
+func (s *tracedStore) BatchTodoTasks(ctx context.Context, request todo.TodoBatchRequest) (response todo.TodoBatchResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/BatchTodoTasks",
+		attribute.String("todo.batch.mode", request.Mode), attribute.Int("todo.count", len(request.Operations)))
+	defer func() { End(span, err) }()
+	response, err = s.store.BatchTodoTasks(ctx, request)
+	span.SetAttributes(attribute.Bool("todo.batch.committed", response.Committed))
+	return response, err
+}
+
+func (s *tracedStore) CreateList(ctx context.Context, input todo.ListRequestInput, owner string) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/CreateList")
+	defer func() { End(span, err) }()
+	return s.store.CreateList(ctx, input, owner)
+}
+
+func (s *tracedStore) GetList(ctx context.Context, id int) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/GetList", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.store.GetList(ctx, id)
+}
+
+func (s *tracedStore) ListLists(ctx context.Context, member string) (lists []todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/ListLists")
+	defer func() { End(span, err) }()
+	lists, err = s.store.ListLists(ctx, member)
+	span.SetAttributes(attribute.Int("todo.count", len(lists)))
+	return lists, err
+}
+
+func (s *tracedStore) UpdateList(ctx context.Context, input todo.ListRequestInput) (response todo.ListResponse, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/UpdateList", attribute.Int("todo.list.id", input.Id))
+	defer func() { End(span, err) }()
+	return s.store.UpdateList(ctx, input)
+}
+
+func (s *tracedStore) DeleteList(ctx context.Context, id int) (err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/DeleteList", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.store.DeleteList(ctx, id)
+}
+
+func (s *tracedStore) PutListMember(ctx context.Context, id int, member todo.ListMember) (err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/PutListMember", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.store.PutListMember(ctx, id, member)
+}
+
+func (s *tracedStore) DeleteListMember(ctx context.Context, id int, subject string) (err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/DeleteListMember", attribute.Int("todo.list.id", id))
+	defer func() { End(span, err) }()
+	return s.store.DeleteListMember(ctx, id, subject)
+}
+
+func (s *tracedStore) ListRoles(ctx context.Context, subject string) (roles map[int]string, err error) {
+	ctx, span := Start(ctx, "store.StoreSvc/ListRoles")
+	defer func() { End(span, err) }()
+	roles, err = s.store.ListRoles(ctx, subject)
+	span.SetAttributes(attribute.Int("todo.count", len(roles)))
+	return roles, err
+}
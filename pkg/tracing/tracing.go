@@ -0,0 +1,165 @@
+// Package tracing sets up OpenTelemetry tracing and starts the spans of the
+// service layers
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"os"
+	"strings"
+)
+
+// instrumentation names the tracer of the service spans
+const instrumentation = "github.com/RanbirSingh-Velotio/todo-service"
+
+// Span exporters selectable with Options.Exporter
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+// OTLP protocols selectable with Options.Protocol
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http"
+)
+
+// Options configures where spans are exported to
+type Options struct {
+	// ServiceName and Environment identify the service in every span
+	ServiceName string
+	Environment string
+	// Exporter is none, otlp, stdout or file
+	Exporter string
+	// Endpoint is the host:port of the OTLP collector, Protocol either grpc or http
+	Endpoint string
+	Protocol string
+	// Insecure sends OTLP spans without TLS
+	Insecure bool
+	// File is the file spans are appended to by the file exporter
+	File string
+	// SampleRatio is the share of new traces recorded, traces started by
+	// the caller follow the caller's decision
+	SampleRatio float64
+}
+
+// Provider exports the spans of the service until it is shut down
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	closer   io.Closer
+}
+
+// Init installs the tracer provider configured by options along with the
+// W3C trace context propagator. With the none exporter spans are still
+// propagated but not recorded.
+func Init(ctx context.Context, options Options) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if options.Exporter == "" || options.Exporter == ExporterNone {
+		return &Provider{}, nil
+	}
+
+	exporter, closer, err := newExporter(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(options.ServiceName),
+		semconv.DeploymentEnvironment(options.Environment),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return &Provider{provider: provider, closer: closer}, nil
+}
+
+// newExporter returns the exporter named by options.Exporter along with the
+// file it writes to, if any
+func newExporter(ctx context.Context, options Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch options.Exporter {
+	case ExporterOTLP:
+		var client otlptrace.Client
+		switch strings.ToLower(options.Protocol) {
+		case ProtocolGRPC, "":
+			grpcOptions := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.Endpoint)}
+			if options.Insecure {
+				grpcOptions = append(grpcOptions, otlptracegrpc.WithInsecure())
+			}
+			client = otlptracegrpc.NewClient(grpcOptions...)
+		case ProtocolHTTP:
+			httpOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+			if options.Insecure {
+				httpOptions = append(httpOptions, otlptracehttp.WithInsecure())
+			}
+			client = otlptracehttp.NewClient(httpOptions...)
+		default:
+			return nil, nil, fmt.Errorf("unknown OTLP protocol %q", options.Protocol)
+		}
+		exporter, err := otlptrace.New(ctx, client)
+		return exporter, nil, err
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case ExporterFile:
+		file, err := os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	}
+	return nil, nil, fmt.Errorf("unknown span exporter %q", options.Exporter)
+}
+
+// Shutdown exports the spans still buffered, giving up once ctx is done
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+	err := p.provider.Shutdown(ctx)
+	if p.closer != nil {
+		err = errors.Join(err, p.closer.Close())
+	}
+	return err
+}
+
+// Tracer returns the tracer of the service spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err, when not nil, on span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	todoService "github.com/RanbirSingh-Velotio/todo-service/pkg/todo/service"
+	"github.com/RanbirSingh-Velotio/todo-service/store/memory"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"path/filepath"
+	"testing"
+)
+
+// recordSpans installs a tracer provider recording every ended span
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestInstrumentService(t *testing.T) {
+	recorder := recordSpans(t)
+	st := memory.New()
+	ctx := context.Background()
+	if _, err := st.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Priority: todo.PriorityMedium}); err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	service := InstrumentService(todoService.New(InstrumentStore(st)))
+
+	if _, err := service.TodoGetRequest(ctx, todo.TodoQuery{Limit: todo.DefaultLimit}); err != nil {
+		t.Fatalf("TodoGetRequest() err = %v\n", err)
+	}
+	if _, err := service.TodoDeleteRequest(ctx, []int{9}, 0); !errors.Is(err, todo.ErrNotFound) {
+		t.Fatalf("TodoDeleteRequest() err = %v, want %v\n", err, todo.ErrNotFound)
+	}
+
+	spans := recorder.Ended()
+	want := []string{"store.StoreSvc/ListTodoTasks", "todo.Service/TodoGetRequest", "store.StoreSvc/DeleteTodoTaskByID", "todo.Service/TodoDeleteRequest"}
+	if len(spans) != len(want) {
+		t.Fatalf("ended spans = %d, want %d\n", len(spans), len(want))
+	}
+	for i, span := range spans {
+		if span.Name() != want[i] {
+			t.Errorf("span %d = %v, want %v\n", i, span.Name(), want[i])
+		}
+	}
+	// Store spans are children of the service spans
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("%v parent = %v, want %v\n", spans[0].Name(), spans[0].Parent().SpanID(), spans[1].SpanContext().SpanID())
+	}
+	count := false
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "todo.count" && attr.Value.AsInt64() == 1 {
+			count = true
+		}
+	}
+	if !count {
+		t.Errorf("%v attributes = %v, want todo.count 1\n", spans[0].Name(), spans[0].Attributes())
+	}
+	if spans[3].Status().Code != codes.Error {
+		t.Errorf("%v status = %v, want %v\n", spans[3].Name(), spans[3].Status().Code, codes.Error)
+	}
+}
+
+func TestInit(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	file := filepath.Join(t.TempDir(), "traces.json")
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{"none", Options{Exporter: ExporterNone}, false},
+		{"file", Options{Exporter: ExporterFile, File: file, SampleRatio: 1}, false},
+		{"otlp http", Options{Exporter: ExporterOTLP, Endpoint: "localhost:4318", Protocol: ProtocolHTTP, Insecure: true, SampleRatio: 1}, false},
+		{"otlp protocol", Options{Exporter: ExporterOTLP, Endpoint: "localhost:4318", Protocol: "udp"}, true},
+		{"unknown exporter", Options{Exporter: "zipkin"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := Init(context.Background(), tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Init() err = %v, wantErr %v\n", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := provider.Shutdown(context.Background()); err != nil {
+					t.Errorf("Shutdown() err = %v\n", err)
+				}
+			}
+		})
+	}
+}
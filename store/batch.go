@@ -0,0 +1,43 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+)
+
+// Writer is the part of StoreSvc the operations of a batch are applied with
+type Writer interface {
+	CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error)
+	UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error)
+	DeleteTodoTaskByID(ctx context.Context, id []int, version int) (todo.TodoDeleteResponse, error)
+}
+
+// ApplyOperation applies op with w, stores implement BatchTodoTasks by
+// calling it for every operation inside their transaction
+func ApplyOperation(ctx context.Context, w Writer, op todo.TodoBatchOperation) todo.TodoBatchResult {
+	result := todo.TodoBatchResult{Op: op.Op}
+	switch op.Op {
+	case todo.OpCreate:
+		response, err := w.CreateTodoTask(ctx, *op.Todo)
+		result.Todo, result.Err = &response, err
+	case todo.OpUpdate:
+		response, err := w.UpdateTodoTaskByID(ctx, *op.Todo)
+		result.Todo, result.Err = &response, err
+	case todo.OpDelete:
+		response, err := w.DeleteTodoTaskByID(ctx, op.Ids, 0)
+		result.Deleted, result.Err = &response, err
+	}
+	if result.Err != nil {
+		result.Todo, result.Deleted = nil, nil
+	}
+	return result
+}
+
+// OperationFailed reports whether err fails only the operation it was
+// returned by. Any other error, such as a lost connection or a done
+// context, fails the whole batch.
+func OperationFailed(err error) bool {
+	return errors.Is(err, todo.ErrNotFound) || errors.Is(err, todo.ErrConflict) || errors.Is(err, todo.ErrValidation) ||
+		errors.Is(err, todo.ErrPreconditionFailed) || errors.Is(err, todo.ErrForbidden)
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"sort"
+	"time"
+)
+
+// copyList returns l with its own copies of the pointer and slice fields,
+// so callers cannot change stored lists
+func copyList(l todo.ListResponse) todo.ListResponse {
+	l.CreatedAt = copyTime(l.CreatedAt)
+	l.UpdatedAt = copyTime(l.UpdatedAt)
+	l.Members = append([]todo.ListMember{}, l.Members...)
+	return l
+}
+
+// checkLastOwner fails with ErrConflict when subject is the only owner of l
+func checkLastOwner(l todo.ListResponse, subject string) error {
+	if l.MemberRole(subject) == todo.ListRoleOwner && l.Owners() == 1 {
+		return fmt.Errorf("%w: %s is the last owner of list %d", todo.ErrConflict, subject, l.Id)
+	}
+	return nil
+}
+
+func (s *StoreSvc) CreateList(ctx context.Context, input todo.ListRequestInput, owner string) (todo.ListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	l := todo.ListResponse{
+		Id:          s.nextListID,
+		Name:        input.Name,
+		Description: input.Description,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+	}
+	if owner != "" {
+		l.Members = []todo.ListMember{{Subject: owner, Role: todo.ListRoleOwner}}
+	}
+	s.lists[l.Id] = copyList(l)
+	s.nextListID++
+	return copyList(l), nil
+}
+
+func (s *StoreSvc) GetList(ctx context.Context, id int) (todo.ListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.lists[id]
+	if !ok {
+		return todo.ListResponse{}, fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	}
+	return copyList(l), nil
+}
+
+func (s *StoreSvc) ListLists(ctx context.Context, member string) ([]todo.ListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	lists := []todo.ListResponse{}
+	for _, l := range s.lists {
+		if member == "" || l.MemberRole(member) != "" {
+			lists = append(lists, copyList(l))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Id < lists[j].Id })
+	return lists, nil
+}
+
+func (s *StoreSvc) UpdateList(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.ListResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lists[input.Id]
+	if !ok {
+		return todo.ListResponse{}, fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, input.Id)
+	}
+	now := time.Now().UTC()
+	l.Name = input.Name
+	l.Description = input.Description
+	l.UpdatedAt = &now
+	s.lists[l.Id] = copyList(l)
+
+	l = copyList(l)
+	l.Message = "Success"
+	return l, nil
+}
+
+func (s *StoreSvc) DeleteList(ctx context.Context, id int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lists[id]; !ok {
+		return fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	}
+	delete(s.lists, id)
+	now := time.Now().UTC()
+	for taskID, t := range s.todos {
+		if t.ListId == id {
+			t.ListId = 0
+			t.UpdatedAt = &now
+			t.Version++
+			s.todos[taskID] = copyTodo(t)
+		}
+	}
+	return nil
+}
+
+func (s *StoreSvc) PutListMember(ctx context.Context, id int, member todo.ListMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lists[id]
+	if !ok {
+		return fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	}
+	if member.Role != todo.ListRoleOwner {
+		if err := checkLastOwner(l, member.Subject); err != nil {
+			return err
+		}
+	}
+	members := []todo.ListMember{member}
+	for _, m := range l.Members {
+		if m.Subject != member.Subject {
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Subject < members[j].Subject })
+	now := time.Now().UTC()
+	l.Members = members
+	l.UpdatedAt = &now
+	s.lists[id] = copyList(l)
+	return nil
+}
+
+func (s *StoreSvc) DeleteListMember(ctx context.Context, id int, subject string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.lists[id]
+	if !ok || l.MemberRole(subject) == "" {
+		return fmt.Errorf("%w: %s is not a member of list %d", todo.ErrNotFound, subject, id)
+	}
+	if err := checkLastOwner(l, subject); err != nil {
+		return err
+	}
+	members := []todo.ListMember{}
+	for _, m := range l.Members {
+		if m.Subject != subject {
+			members = append(members, m)
+		}
+	}
+	now := time.Now().UTC()
+	l.Members = members
+	l.UpdatedAt = &now
+	s.lists[id] = copyList(l)
+	return nil
+}
+
+func (s *StoreSvc) ListRoles(ctx context.Context, subject string) (map[int]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := map[int]string{}
+	for id, l := range s.lists {
+		if role := l.MemberRole(subject); role != "" {
+			roles[id] = role
+		}
+	}
+	return roles, nil
+}
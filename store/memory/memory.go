@@ -0,0 +1,407 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoreSvc keeps tasks in memory, for tests and demos. It is safe for
+// concurrent use and loses every task when the process exits.
+type StoreSvc struct {
+	mu     sync.RWMutex
+	todos  map[int]todo.TodoResponse
+	nextID int
+	// lists hold their members ordered by subject
+	lists      map[int]todo.ListResponse
+	nextListID int
+}
+
+func New() *StoreSvc {
+	store := &StoreSvc{
+		todos:      map[int]todo.TodoResponse{},
+		nextID:     1,
+		lists:      map[int]todo.ListResponse{},
+		nextListID: 1,
+	}
+	return store
+}
+
+// copyTodo returns t with its own copies of the pointer and slice fields,
+// so callers cannot change stored tasks
+func copyTodo(t todo.TodoResponse) todo.TodoResponse {
+	t.DueAt = copyTime(t.DueAt)
+	t.CreatedAt = copyTime(t.CreatedAt)
+	t.UpdatedAt = copyTime(t.UpdatedAt)
+	t.CompletedAt = copyTime(t.CompletedAt)
+	t.DeletedAt = copyTime(t.DeletedAt)
+	t.Tags = append([]string{}, t.Tags...)
+	return t
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// compareTodos orders a before b by field, returning a negative number,
+// zero or a positive number. Ties are left to the caller.
+func compareTodos(field string, a, b todo.TodoResponse) int {
+	switch field {
+	case todo.SortName:
+		return strings.Compare(a.Name, b.Name)
+	case todo.SortCreatedAt:
+		return compareTimes(*a.CreatedAt, *b.CreatedAt)
+	case todo.SortUpdatedAt:
+		return compareTimes(*a.UpdatedAt, *b.UpdatedAt)
+	}
+	return 0
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// afterCursor reports whether t comes after the cursor position when sorted by field
+func afterCursor(field string, desc bool, t todo.TodoResponse, cursor todo.Cursor) bool {
+	position := todo.TodoResponse{Id: cursor.Id, Name: cursor.Value}
+	if field == todo.SortCreatedAt || field == todo.SortUpdatedAt {
+		value, _ := cursor.Time()
+		position.CreatedAt, position.UpdatedAt = &value, &value
+	}
+	order := compareTodos(field, t, position)
+	if order == 0 {
+		order = t.Id - position.Id
+	}
+	if desc {
+		return order < 0
+	}
+	return order > 0
+}
+
+// ListTodoTasks returns one page of the tasks matching query, ordered by
+// query.Sort and then id, with the same keyset pages as the SQL stores
+func (s *StoreSvc) ListTodoTasks(ctx context.Context, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoListResponse{}, err
+	}
+	field, desc := query.SortField()
+	var cursor todo.Cursor
+	if query.Cursor != "" {
+		var err error
+		if cursor, err = query.DecodeCursor(); err != nil {
+			return todo.TodoListResponse{}, err
+		}
+	}
+
+	ids := map[int]bool{}
+	for _, id := range query.Ids {
+		ids[id] = true
+	}
+	q := strings.ToLower(query.Q)
+	scope := store.ScopeFromContext(ctx)
+
+	s.mu.RLock()
+	var todos []todo.TodoResponse
+	for _, t := range s.todos {
+		if (t.DeletedAt != nil) != query.Deleted || !scope.CanRead(t.OwnerId, t.ListId) {
+			continue
+		}
+		if query.Owner != "" && t.OwnerId != query.Owner {
+			continue
+		}
+		if query.ListId != 0 && t.ListId != query.ListId {
+			continue
+		}
+		if len(ids) > 0 && !ids[t.Id] {
+			continue
+		}
+		if query.Completed != nil && t.Completed != *query.Completed {
+			continue
+		}
+		if q != "" && !strings.Contains(strings.ToLower(t.Name), q) {
+			continue
+		}
+		if query.Cursor != "" && !afterCursor(field, desc, t, cursor) {
+			continue
+		}
+		todos = append(todos, copyTodo(t))
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(todos, func(i, j int) bool {
+		order := compareTodos(field, todos[i], todos[j])
+		if order == 0 {
+			order = todos[i].Id - todos[j].Id
+		}
+		if desc {
+			return order > 0
+		}
+		return order < 0
+	})
+
+	response := todo.TodoListResponse{Items: todos}
+	if response.Items == nil {
+		response.Items = []todo.TodoResponse{}
+	}
+	if len(todos) > query.Limit {
+		response.Items = todos[:query.Limit]
+		response.NextCursor = query.NextCursor(response.Items[query.Limit-1])
+	}
+	return response, nil
+}
+
+func (s *StoreSvc) CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	t := todo.TodoResponse{
+		Id:          s.nextID,
+		Name:        requestInput.Name,
+		Description: requestInput.Description,
+		Completed:   requestInput.Completed,
+		DueAt:       requestInput.DueAt,
+		Priority:    requestInput.Priority,
+		Tags:        requestInput.Tags,
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+		Version:     1,
+		OwnerId:     store.ScopeFromContext(ctx).Owner,
+		ListId:      requestInput.ListId,
+	}
+	if t.Completed {
+		t.CompletedAt = &now
+	}
+	t = copyTodo(t)
+	s.todos[t.Id] = t
+	s.nextID++
+	return copyTodo(t), nil
+}
+
+func (s *StoreSvc) GetTodoTaskByID(ctx context.Context, ids []int) ([]todo.TodoResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no task ids given", todo.ErrNotFound)
+	}
+
+	scope := store.ScopeFromContext(ctx)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var todos []todo.TodoResponse
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if t, ok := s.todos[id]; ok && t.DeletedAt == nil && scope.CanRead(t.OwnerId, t.ListId) && !seen[id] {
+			seen[id] = true
+			todos = append(todos, copyTodo(t))
+		}
+	}
+	if len(todos) == 0 {
+		return nil, fmt.Errorf("%w: no task found with ids %v", todo.ErrNotFound, ids)
+	}
+	sort.Slice(todos, func(i, j int) bool { return todos[i].Id < todos[j].Id })
+	return todos, nil
+}
+
+// missedWrite returns the error of a write to the task with id that
+// expected version: ErrForbidden when the scope sees the task but cannot
+// change it, ErrPreconditionFailed when the task is at another version,
+// ErrNotFound when there is no such task in scope. It is called with s.mu
+// held.
+func (s *StoreSvc) missedWrite(scope store.Scope, id, version int) error {
+	t, ok := s.todos[id]
+	switch {
+	case !ok || t.DeletedAt != nil || !scope.CanRead(t.OwnerId, t.ListId):
+	case !scope.CanWrite(t.OwnerId, t.ListId):
+		return fmt.Errorf("%w: task %d is in list %d, which you may only view", todo.ErrForbidden, id, t.ListId)
+	case version != 0 && t.Version != version:
+		return fmt.Errorf("%w: task %d is at version %d, not %d", todo.ErrPreconditionFailed, id, t.Version, version)
+	}
+	return fmt.Errorf("%w: no task found with id %d", todo.ErrNotFound, id)
+}
+
+func (s *StoreSvc) DeleteTodoTaskByID(ctx context.Context, id []int, version int) (todo.TodoDeleteResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoDeleteResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	scope := store.ScopeFromContext(ctx)
+	var deleted []int
+	for _, taskID := range id {
+		if t, ok := s.todos[taskID]; ok && t.DeletedAt == nil && scope.CanWrite(t.OwnerId, t.ListId) && (version == 0 || t.Version == version) {
+			t.DeletedAt = &now
+			t.Version++
+			s.todos[taskID] = copyTodo(t)
+			deleted = append(deleted, taskID)
+		}
+	}
+	if len(deleted) == 0 && len(id) == 1 {
+		return todo.TodoDeleteResponse{}, s.missedWrite(scope, id[0], version)
+	}
+	if len(deleted) == 0 {
+		return todo.TodoDeleteResponse{}, fmt.Errorf("%w: no task found with ids %v", todo.ErrNotFound, id)
+	}
+	return todo.NewTodoDeleteResponse(id, deleted), nil
+}
+
+func (s *StoreSvc) RestoreTodoTaskByID(ctx context.Context, id int) (todo.TodoResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scope := store.ScopeFromContext(ctx)
+	t, ok := s.todos[id]
+	if !ok || t.DeletedAt == nil || !scope.CanRead(t.OwnerId, t.ListId) {
+		return todo.TodoResponse{}, fmt.Errorf("%w: no task with id %d in the trash", todo.ErrNotFound, id)
+	}
+	if !scope.CanWrite(t.OwnerId, t.ListId) {
+		return todo.TodoResponse{}, fmt.Errorf("%w: task %d is in list %d, which you may only view", todo.ErrForbidden, id, t.ListId)
+	}
+	now := time.Now().UTC()
+	t.DeletedAt = nil
+	t.UpdatedAt = &now
+	t.Version++
+	s.todos[id] = copyTodo(t)
+
+	t = copyTodo(t)
+	t.Message = "Success"
+	return t, nil
+}
+
+func (s *StoreSvc) PurgeTodoTasks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scope := store.ScopeFromContext(ctx)
+	purged := 0
+	for id, t := range s.todos {
+		if t.DeletedAt != nil && t.DeletedAt.Before(deletedBefore) && scope.CanWrite(t.OwnerId, t.ListId) {
+			delete(s.todos, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (s *StoreSvc) UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scope := store.ScopeFromContext(ctx)
+	t, ok := s.todos[requestInput.Id]
+	if !ok || t.DeletedAt != nil || !scope.CanWrite(t.OwnerId, t.ListId) || (requestInput.Version != 0 && t.Version != requestInput.Version) {
+		return todo.TodoResponse{}, s.missedWrite(scope, requestInput.Id, requestInput.Version)
+	}
+	now := time.Now().UTC()
+	t.Name = requestInput.Name
+	t.Description = requestInput.Description
+	t.Completed = requestInput.Completed
+	t.DueAt = requestInput.DueAt
+	t.Priority = requestInput.Priority
+	t.Tags = requestInput.Tags
+	t.ListId = requestInput.ListId
+	t.UpdatedAt = &now
+	t.Version++
+	// completed_at keeps its first value while the task stays completed
+	switch {
+	case !t.Completed:
+		t.CompletedAt = nil
+	case t.CompletedAt == nil:
+		t.CompletedAt = &now
+	}
+	t = copyTodo(t)
+	s.todos[t.Id] = t
+
+	t = copyTodo(t)
+	t.Message = "Success"
+	return t, nil
+}
+
+func (s *StoreSvc) CountTodoTasks(ctx context.Context) (todo.TodoCounts, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoCounts{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	scope := store.ScopeFromContext(ctx)
+	var counts todo.TodoCounts
+	for _, t := range s.todos {
+		if t.DeletedAt != nil || !scope.CanRead(t.OwnerId, t.ListId) {
+			continue
+		}
+		if t.Completed {
+			counts.Completed++
+		} else {
+			counts.Open++
+		}
+	}
+	return counts, nil
+}
+
+// BatchTodoTasks applies the operations of request in order to a copy of
+// the tasks, which replaces them once the batch is committed. The store is
+// locked meanwhile, so no other request sees a partly applied batch.
+func (s *StoreSvc) BatchTodoTasks(ctx context.Context, request todo.TodoBatchRequest) (todo.TodoBatchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoBatchResponse{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &StoreSvc{todos: make(map[int]todo.TodoResponse, len(s.todos)), nextID: s.nextID}
+	for id, t := range s.todos {
+		tx.todos[id] = t
+	}
+
+	response := todo.TodoBatchResponse{Mode: request.Mode, Results: make([]todo.TodoBatchResult, 0, len(request.Operations))}
+	for i, op := range request.Operations {
+		// A failed operation changes no task, so there is nothing to roll back in best effort mode
+		result := store.ApplyOperation(ctx, tx, op)
+		response.Results = append(response.Results, result)
+		if result.Err != nil && !store.OperationFailed(result.Err) {
+			return todo.TodoBatchResponse{}, result.Err
+		}
+		if result.Err != nil && request.Mode == todo.BatchAtomic {
+			for _, rest := range request.Operations[i+1:] {
+				response.Results = append(response.Results, todo.TodoBatchResult{Op: rest.Op})
+			}
+			response.Abort(i)
+			return response, nil
+		}
+	}
+
+	s.todos, s.nextID = tx.todos, tx.nextID
+	response.Committed = true
+	return response, nil
+}
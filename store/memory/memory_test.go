@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/RanbirSingh-Velotio/todo-service/store/storetest"
+	"sync"
+	"testing"
+)
+
+func TestStoreSvc(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.StoreSvc {
+		return New()
+	})
+}
+
+func TestStoreSvc_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			created, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: fmt.Sprintf("task %d", i), Priority: todo.PriorityMedium})
+			if err != nil {
+				t.Errorf("CreateTodoTask() err = %v\n", err)
+				return
+			}
+			s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: created.Id, Name: "renamed", Completed: true, Priority: todo.PriorityMedium})
+			s.ListTodoTasks(ctx, todo.TodoQuery{Limit: todo.DefaultLimit})
+		}(i)
+	}
+	wg.Wait()
+
+	response, err := s.ListTodoTasks(ctx, todo.TodoQuery{Limit: todo.MaxLimit})
+	if err != nil {
+		t.Fatalf("ListTodoTasks() err = %v\n", err)
+	}
+	if len(response.Items) != 50 {
+		t.Errorf("ListTodoTasks() got %v tasks, want %v\n", len(response.Items), 50)
+	}
+}
+
+func TestStoreSvc_SearchTodoTasks(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	inputs := []todo.TodoRequestInput{
+		{Name: "Buy milk", Description: "semi skimmed from the corner shop"},
+		{Name: "Walk the dog", Description: "remember to buy dog food on the way"},
+		{Name: "Pay rent", Completed: true, Tags: []string{"bills"}},
+	}
+	for _, input := range inputs {
+		input.Priority = todo.PriorityMedium
+		if _, err := s.CreateTodoTask(ctx, input); err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+	completed := true
+
+	tests := []struct {
+		name  string
+		query todo.TodoSearchQuery
+		want  []int
+	}{
+		{"name ranks above description", todo.TodoSearchQuery{Q: "buy"}, []int{1, 2}},
+		{"prefix", todo.TodoSearchQuery{Q: "wal"}, []int{2}},
+		{"all words must match", todo.TodoSearchQuery{Q: "dog food"}, []int{2}},
+		{"tags", todo.TodoSearchQuery{Q: "bills"}, []int{3}},
+		{"completed filter", todo.TodoSearchQuery{Q: "pay buy", Completed: &completed}, nil},
+		{"operators are literal", todo.TodoSearchQuery{Q: `milk" OR "dog`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.Limit = todo.DefaultSearchLimit
+			response, err := s.SearchTodoTasks(ctx, query)
+			if err != nil {
+				t.Fatalf("SearchTodoTasks() err = %v\n", err)
+			}
+			var got []int
+			for _, item := range response.Items {
+				got = append(got, item.Id)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("SearchTodoTasks() ids = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+
+	// Tasks of other owners are not found
+	alice := auth.NewContext(ctx, auth.Principal{Subject: "alice"})
+	if response, _ := s.SearchTodoTasks(alice, todo.TodoSearchQuery{Q: "buy", Limit: 1}); len(response.Items) != 0 {
+		t.Errorf("SearchTodoTasks() of alice = %+v, want none\n", response.Items)
+	}
+
+	// unless they are in a list alice is a member of
+	list, err := s.CreateList(ctx, todo.ListRequestInput{Name: "home"}, "alice")
+	if err != nil {
+		t.Fatalf("CreateList() err = %v\n", err)
+	}
+	todos, err := s.GetTodoTaskByID(ctx, []int{1})
+	if err != nil {
+		t.Fatalf("GetTodoTaskByID() err = %v\n", err)
+	}
+	input := todos[0].RequestInput()
+	input.ListId = list.Id
+	if _, err := s.UpdateTodoTaskByID(ctx, input); err != nil {
+		t.Fatalf("UpdateTodoTaskByID() err = %v\n", err)
+	}
+	member := store.NewContext(alice, store.Scope{Owner: "alice", Lists: map[int]string{list.Id: todo.ListRoleViewer}})
+	if response, _ := s.SearchTodoTasks(member, todo.TodoSearchQuery{Q: "buy", Limit: 1}); len(response.Items) != 1 || response.Items[0].Id != 1 {
+		t.Errorf("SearchTodoTasks() of list member = %+v, want task 1\n", response.Items)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  string
+	}{
+		{"Buy milk", []string{"mil"}, "Buy <mark>milk</mark>"},
+		{"milk, milkshake & tea", []string{"milk"}, "<mark>milk</mark>, <mark>milkshake</mark> & tea"},
+		{"Café visit", []string{"caf"}, "<mark>Café</mark> visit"},
+		{"nothing", []string{"milk"}, "nothing"},
+	}
+	for _, tt := range tests {
+		if got := highlight(tt.text, tt.terms); got != tt.want {
+			t.Errorf("highlight(%q) = %v, want %v\n", tt.text, got, tt.want)
+		}
+	}
+}
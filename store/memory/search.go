@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Field weights of a match, a match in the name counts more than one in the tags or description
+const (
+	nameWeight        = 10.0
+	descriptionWeight = 2.0
+	tagWeight         = 5.0
+)
+
+// words splits text the same way search terms are split
+func words(text string) []string {
+	return todo.TodoSearchQuery{Q: text}.Terms()
+}
+
+// countMatches returns how many words of text start with term, ignoring case
+func countMatches(text, term string) int {
+	count := 0
+	for _, word := range words(text) {
+		if strings.HasPrefix(strings.ToLower(word), term) {
+			count++
+		}
+	}
+	return count
+}
+
+// highlight wraps the words of text starting with one of terms in <mark> tags
+func highlight(text string, terms []string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		lower := strings.ToLower(word)
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) {
+				word = "<mark>" + word + "</mark>"
+				break
+			}
+		}
+		b.WriteString(word)
+		start = -1
+	}
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			flush(i)
+		}
+		if !isWord {
+			b.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
+
+// SearchTodoTasks returns the tasks where every word of query.Q is the
+// prefix of a word in the name, description or tags, best match first
+func (s *StoreSvc) SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return todo.TodoSearchResponse{}, err
+	}
+	response := todo.TodoSearchResponse{Items: []todo.TodoSearchResult{}}
+	terms := query.Terms()
+	if len(terms) == 0 {
+		return response, nil
+	}
+	for i, term := range terms {
+		terms[i] = strings.ToLower(term)
+	}
+
+	scope := store.ScopeFromContext(ctx)
+	s.mu.RLock()
+	for _, t := range s.todos {
+		if t.DeletedAt != nil || !scope.CanRead(t.OwnerId, t.ListId) {
+			continue
+		}
+		if query.Completed != nil && t.Completed != *query.Completed {
+			continue
+		}
+		rank := 0.0
+		matched := true
+		for _, term := range terms {
+			score := nameWeight*float64(countMatches(t.Name, term)) +
+				descriptionWeight*float64(countMatches(t.Description, term)) +
+				tagWeight*float64(countMatches(strings.Join(t.Tags, " "), term))
+			if score == 0 {
+				matched = false
+				break
+			}
+			rank += score
+		}
+		if !matched {
+			continue
+		}
+		response.Items = append(response.Items, todo.TodoSearchResult{
+			TodoResponse: copyTodo(t),
+			Rank:         rank,
+			Highlight: todo.TodoHighlight{
+				Name:        highlight(t.Name, terms),
+				Description: highlight(t.Description, terms),
+			},
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(response.Items, func(i, j int) bool {
+		a, b := response.Items[i], response.Items[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		return a.Id < b.Id
+	})
+	if len(response.Items) > query.Limit {
+		response.Items = response.Items[:query.Limit]
+	}
+	return response, nil
+}
@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestDB(t *testing.T) *sqlx.DB {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("sqlx.Connect() err = %v\n", err)
+	}
+	// Every connection to :memory: opens its own database
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id integer);")},
+		"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id integer);")},
+		"0002_create_b.down.sql": {Data: []byte("DROP TABLE b;")},
+		"README.md":              {Data: []byte("not a migration")},
+	}
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, err := New(db, testFS())
+	if err != nil {
+		t.Fatalf("New() err = %v\n", err)
+	}
+	if got := m.Latest(); got != 2 {
+		t.Errorf("Latest() = %v, want %v\n", got, 2)
+	}
+	// Reading the version of a fresh database leaves it untouched
+	if _, err := m.CurrentVersion(ctx); !errors.Is(err, ErrNotMigrated) {
+		t.Errorf("CurrentVersion() err = %v, want %v\n", err, ErrNotMigrated)
+	}
+	var tables int
+	if err := db.Get(&tables, "SELECT COUNT(*) FROM sqlite_master"); err != nil || tables != 0 {
+		t.Errorf("CurrentVersion() created %d tables, err = %v, want none\n", tables, err)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() err = %v\n", err)
+	}
+	// Applying again is a no-op
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("second Up() err = %v\n", err)
+	}
+	if version, _ := m.Version(ctx); version != 2 {
+		t.Errorf("Version() = %v, want %v\n", version, 2)
+	}
+	if version, err := m.CurrentVersion(ctx); err != nil || version != 2 {
+		t.Errorf("CurrentVersion() = %v, %v, want %v\n", version, err, 2)
+	}
+	if _, err := db.Exec("INSERT INTO b (id) VALUES (1)"); err != nil {
+		t.Errorf("table b was not created: %v\n", err)
+	}
+
+	if err := m.Down(ctx, 1); err != nil {
+		t.Fatalf("Down() err = %v\n", err)
+	}
+	if version, _ := m.Version(ctx); version != 1 {
+		t.Errorf("Version() after Down = %v, want %v\n", version, 1)
+	}
+	if _, err := db.Exec("SELECT * FROM b"); err == nil {
+		t.Errorf("table b still exists after Down\n")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() err = %v\n", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Applied {
+		t.Errorf("Status() = %+v, want only 0001 applied\n", statuses)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := testFS()
+	m, _ := New(db, fsys)
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() err = %v\n", err)
+	}
+
+	fsys["0001_create_a.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE a (id integer, name text);")}
+	m, _ = New(db, fsys)
+	if err := m.Up(ctx); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up() err = %v, want %v\n", err, ErrChecksumMismatch)
+	}
+}
+
+func TestMigrator_UnknownVersion(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, _ := New(db, testFS())
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() err = %v\n", err)
+	}
+
+	fsys := testFS()
+	delete(fsys, "0002_create_b.up.sql")
+	delete(fsys, "0002_create_b.down.sql")
+	m, _ = New(db, fsys)
+	if err := m.Up(ctx); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Up() err = %v, want %v\n", err, ErrUnknownVersion)
+	}
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := testFS()
+	fsys["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE c (id integer); INSERT INTO missing VALUES (1);")}
+	m, _ := New(db, fsys)
+	if err := m.Up(ctx); err == nil {
+		t.Fatalf("Up() expected error\n")
+	}
+	if version, _ := m.Version(ctx); version != 2 {
+		t.Errorf("Version() = %v, want %v\n", version, 2)
+	}
+	if _, err := db.Exec("SELECT * FROM c"); err == nil {
+		t.Errorf("table c of the failed migration was not rolled back\n")
+	}
+}
+
+func TestNew_MissingUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+	}
+	if _, err := New(nil, fsys); err == nil {
+		t.Errorf("New() expected error for a migration without up file\n")
+	}
+}
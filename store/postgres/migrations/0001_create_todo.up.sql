@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS todo (
+	id integer generated by default as identity primary key,
+	name text not null,
+	description text not null default '',
+	completed boolean not null default false,
+	due_at timestamptz,
+	priority text not null default 'medium',
+	tags text not null default '[]',
+	created_at timestamptz not null default CURRENT_TIMESTAMP,
+	updated_at timestamptz not null default CURRENT_TIMESTAMP,
+	completed_at timestamptz
+);
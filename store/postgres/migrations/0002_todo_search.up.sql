@@ -0,0 +1,9 @@
+-- Name matches weigh most, then tags, then the description. The simple
+-- configuration does no stemming so words match as typed, like the FTS4 index in SQLite.
+ALTER TABLE todo ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', name), 'A') ||
+	setweight(to_tsvector('simple', tags), 'B') ||
+	setweight(to_tsvector('simple', description), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS todo_search_idx ON todo USING gin (search);
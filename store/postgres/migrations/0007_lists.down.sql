@@ -0,0 +1,7 @@
+DROP INDEX todo_list_id;
+
+ALTER TABLE todo DROP COLUMN list_id;
+
+DROP TABLE list_members;
+
+DROP TABLE lists;
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/sqlstore"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// dialect sorts names bytewise like the other stores rather than by the
+// database locale, timestamptz columns already compare correctly
+var dialect = sqlstore.Dialect{
+	System: "postgresql",
+	Time:   func(expr string) string { return expr },
+	Text:   func(expr string) string { return expr + ` COLLATE "C"` },
+	Like:   "ILIKE",
+	Error:  storeError,
+}
+
+type StoreSvc struct {
+	*sqlstore.Store
+}
+
+func New(db *sqlx.DB) *StoreSvc {
+	store := &StoreSvc{
+		Store: sqlstore.New(db, dialect),
+	}
+	return store
+}
+
+// storeError converts a driver error into one of the todo error kinds
+func storeError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "23": // integrity_constraint_violation
+			return fmt.Errorf("%w: %v", todo.ErrConflict, err)
+		case pqErr.Code.Class() == "40", // transaction_rollback, e.g. serialization failures
+			pqErr.Code == "55P03", // lock_not_available
+			pqErr.Code == "53300", // too_many_connections
+			pqErr.Code == "57P03": // cannot_connect_now
+			return fmt.Errorf("%w: %v", todo.ErrUnavailable, err)
+		}
+	}
+	return fmt.Errorf("%w: %v", todo.ErrInternal, err)
+}
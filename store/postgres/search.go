@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/sqlstore"
+	"strings"
+)
+
+// tsQuery turns free text into a tsquery where every word must match as a
+// prefix. Terms only hold letters and digits, so they need no quoting.
+func tsQuery(q string) string {
+	words := todo.TodoSearchQuery{Q: q}.Terms()
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, strings.ToLower(word)+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+type searchRow struct {
+	sqlstore.TodoRow
+	Rank                 float64 `db:"rank"`
+	NameHighlight        string  `db:"name_highlight"`
+	DescriptionHighlight string  `db:"description_highlight"`
+}
+
+// SearchTodoTasks returns the tasks matching query.Q ranked by ts_rank, best match first
+func (s *StoreSvc) SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error) {
+	response := todo.TodoSearchResponse{Items: []todo.TodoSearchResult{}}
+	match := tsQuery(query.Q)
+	if match == "" {
+		return response, nil
+	}
+
+	where := []string{"t.search @@ q.query", "t.deleted_at IS NULL"}
+	args := []interface{}{match}
+	if clause, scopeArgs := sqlstore.ScopeClause(ctx, "t."); clause != "" {
+		where = append(where, clause)
+		args = append(args, scopeArgs...)
+	}
+	if query.Completed != nil {
+		where = append(where, "t.completed = ?")
+		args = append(args, *query.Completed)
+	}
+	args = append(args, query.Limit)
+
+	searchSQL := `SELECT t.` + strings.ReplaceAll(sqlstore.TodoColumns, ", ", ", t.") + `, ts_rank(t.search, q.query) AS rank,
+		ts_headline('simple', t.name, q.query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS name_highlight,
+		ts_headline('simple', t.description, q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=16, MinWords=8') AS description_highlight
+		FROM todo t, to_tsquery('simple', ?) AS q(query)
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY rank DESC, t.id LIMIT ?`
+
+	db := s.DB()
+	var rows []searchRow
+	queryCtx, span := s.StartQuery(ctx, "todo", searchSQL)
+	err := db.SelectContext(queryCtx, &rows, db.Rebind(searchSQL), args...)
+	sqlstore.EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return todo.TodoSearchResponse{}, s.Error(ctx, err)
+	}
+	for _, row := range rows {
+		response.Items = append(response.Items, todo.TodoSearchResult{
+			TodoResponse: row.Response(ctx),
+			Rank:         row.Rank,
+			Highlight: todo.TodoHighlight{
+				Name:        row.NameHighlight,
+				Description: row.DescriptionHighlight,
+			},
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"sort"
+)
+
+// Scope is the set of tasks a store call may see and change
+type Scope struct {
+	// Owner is the owner of the tasks the call creates and, unless All is
+	// set, the only owner whose tasks it sees outside Lists
+	Owner string
+	// All lets the call see the tasks of every owner
+	All bool
+	// Lists holds the role of the call in the lists whose tasks it sees
+	// besides its own, by list id. Viewers see the tasks of a list, editors
+	// and owners also change them.
+	Lists map[int]string
+}
+
+type scopeKey struct{}
+
+// NewContext returns a copy of ctx carrying scope, which store calls made
+// with it use instead of the scope of the principal
+func NewContext(ctx context.Context, scope Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope stored in ctx by NewContext, or else
+// the scope of the principal carried by ctx, which sees its own tasks only
+// unless it holds auth.RoleAdmin. Calls made without a principal, such as
+// the trash purge or requests served with authentication disabled, see
+// every task.
+func ScopeFromContext(ctx context.Context) Scope {
+	if scope, ok := ctx.Value(scopeKey{}).(Scope); ok {
+		return scope
+	}
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return Scope{All: true}
+	}
+	return Scope{Owner: p.Subject, All: p.HasRole(auth.RoleAdmin)}
+}
+
+// CanRead reports whether the scope sees the tasks of owner in the list with listID
+func (s Scope) CanRead(owner string, listID int) bool {
+	return s.All || s.Owner == owner || (listID != 0 && s.Lists[listID] != "")
+}
+
+// CanWrite reports whether the scope changes the tasks of owner in the list with listID
+func (s Scope) CanWrite(owner string, listID int) bool {
+	return s.All || s.Owner == owner || (listID != 0 && todo.ListRoleAtLeast(s.Lists[listID], todo.ListRoleEditor))
+}
+
+// ReadLists returns the ids of the lists whose tasks the scope sees, in order
+func (s Scope) ReadLists() []int {
+	return s.lists(todo.ListRoleViewer)
+}
+
+// WriteLists returns the ids of the lists whose tasks the scope changes, in order
+func (s Scope) WriteLists() []int {
+	return s.lists(todo.ListRoleEditor)
+}
+
+func (s Scope) lists(min string) []int {
+	var ids []int
+	for id, role := range s.Lists {
+		if todo.ListRoleAtLeast(role, min) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
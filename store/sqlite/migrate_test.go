@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"github.com/RanbirSingh-Velotio/todo-service/store/migrate"
+	"github.com/jmoiron/sqlx"
+	"testing"
+)
+
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Connect(DriverName, ":memory:")
+	if err != nil {
+		t.Fatalf("sqlx.Connect() err = %v\n", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	m, err := migrate.New(db, Migrations())
+	if err != nil {
+		t.Fatalf("migrate.New() err = %v\n", err)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() err = %v\n", err)
+	}
+	if version, _ := m.Version(ctx); version != m.Latest() {
+		t.Errorf("Version() = %v, want %v\n", version, m.Latest())
+	}
+
+	// Every migration must be reversible and reapplicable
+	if err := m.Down(ctx, len(m.Migrations())); err != nil {
+		t.Fatalf("Down() err = %v\n", err)
+	}
+	if version, _ := m.Version(ctx); version != 0 {
+		t.Errorf("Version() after Down = %v, want %v\n", version, 0)
+	}
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() after Down err = %v\n", err)
+	}
+}
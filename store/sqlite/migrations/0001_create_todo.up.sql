@@ -0,0 +1,2 @@
+-- Schema of the first release, kept as is so existing databases are adopted
+CREATE TABLE IF NOT EXISTS todo (id integer not null primary key, name text, completed bool);
@@ -0,0 +1,7 @@
+CREATE TABLE todo_old (id integer not null primary key, name text, completed bool);
+
+INSERT INTO todo_old (id, name, completed) SELECT id, name, completed FROM todo;
+
+DROP TABLE todo;
+
+ALTER TABLE todo_old RENAME TO todo;
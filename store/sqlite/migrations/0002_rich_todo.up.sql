@@ -0,0 +1,20 @@
+-- Rebuild the table so ids are AUTOINCREMENT and never reused, and add the rich todo columns
+CREATE TABLE todo_new (
+	id integer primary key autoincrement,
+	name text not null,
+	description text not null default '',
+	completed bool not null default false,
+	due_at datetime,
+	priority text not null default 'medium',
+	tags text not null default '[]',
+	created_at datetime not null default CURRENT_TIMESTAMP,
+	updated_at datetime not null default CURRENT_TIMESTAMP,
+	completed_at datetime
+);
+
+INSERT INTO todo_new (id, name, completed)
+	SELECT id, COALESCE(name, ''), COALESCE(completed, false) FROM todo;
+
+DROP TABLE todo;
+
+ALTER TABLE todo_new RENAME TO todo;
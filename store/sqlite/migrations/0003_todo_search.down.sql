@@ -0,0 +1,9 @@
+DROP TRIGGER todo_fts_after_insert;
+
+DROP TRIGGER todo_fts_after_update;
+
+DROP TRIGGER todo_fts_before_delete;
+
+DROP TRIGGER todo_fts_before_update;
+
+DROP TABLE todo_fts;
@@ -0,0 +1,30 @@
+-- Full-text index over the todo table, kept in sync by triggers. FTS4 is
+-- compiled into every go-sqlite3 build, unlike FTS5.
+-- Tags are indexed from their JSON text, the tokenizer drops the punctuation.
+CREATE VIRTUAL TABLE todo_fts USING fts4(
+	name,
+	description,
+	tags,
+	content="todo",
+	tokenize=unicode61 "remove_diacritics=2"
+);
+
+-- An external content index reads the old values of a row from the todo
+-- table, so they are removed before the row changes
+CREATE TRIGGER todo_fts_before_update BEFORE UPDATE OF name, description, tags ON todo BEGIN
+	DELETE FROM todo_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER todo_fts_before_delete BEFORE DELETE ON todo BEGIN
+	DELETE FROM todo_fts WHERE docid = old.id;
+END;
+
+CREATE TRIGGER todo_fts_after_update AFTER UPDATE OF name, description, tags ON todo BEGIN
+	INSERT INTO todo_fts (docid, name, description, tags) VALUES (new.id, new.name, new.description, new.tags);
+END;
+
+CREATE TRIGGER todo_fts_after_insert AFTER INSERT ON todo BEGIN
+	INSERT INTO todo_fts (docid, name, description, tags) VALUES (new.id, new.name, new.description, new.tags);
+END;
+
+INSERT INTO todo_fts (todo_fts) VALUES ('rebuild');
@@ -0,0 +1,5 @@
+DELETE FROM todo WHERE deleted_at IS NOT NULL;
+
+DROP INDEX todo_deleted_at;
+
+ALTER TABLE todo DROP COLUMN deleted_at;
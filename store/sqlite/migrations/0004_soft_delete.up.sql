@@ -0,0 +1,4 @@
+-- Deleted tasks stay in the table, in the trash, until they are purged
+ALTER TABLE todo ADD COLUMN deleted_at datetime;
+
+CREATE INDEX todo_deleted_at ON todo (deleted_at);
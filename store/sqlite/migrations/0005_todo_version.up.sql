@@ -0,0 +1,3 @@
+-- version is incremented on every write, clients send it back in If-Match
+-- so concurrent edits of a task do not overwrite each other
+ALTER TABLE todo ADD COLUMN version integer NOT NULL DEFAULT 1;
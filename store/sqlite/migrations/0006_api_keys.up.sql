@@ -0,0 +1,11 @@
+-- API keys are stored as their hex SHA-256, the keys themselves are only
+-- shown once when they are created
+CREATE TABLE api_keys (
+	key_hash text PRIMARY KEY,
+	subject text NOT NULL,
+	roles text NOT NULL DEFAULT '',
+	created_at datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	revoked_at datetime
+);
+
+CREATE INDEX api_keys_subject ON api_keys (subject);
@@ -0,0 +1,3 @@
+DROP INDEX todo_owner_id;
+
+ALTER TABLE todo DROP COLUMN owner_id;
@@ -0,0 +1,5 @@
+-- owner_id is the subject of the principal that created the task, tasks
+-- created before authentication have none and are only seen by admins
+ALTER TABLE todo ADD COLUMN owner_id text NOT NULL DEFAULT '';
+
+CREATE INDEX todo_owner_id ON todo (owner_id, deleted_at);
@@ -0,0 +1,23 @@
+-- Lists are shared by their members, each holding the owner, editor or
+-- viewer role. Tasks belong to at most one list, list_id is NULL otherwise.
+CREATE TABLE lists (
+	id integer primary key autoincrement,
+	name text not null,
+	description text not null default '',
+	created_at datetime not null default CURRENT_TIMESTAMP,
+	updated_at datetime not null default CURRENT_TIMESTAMP
+);
+
+CREATE TABLE list_members (
+	list_id integer not null REFERENCES lists (id) ON DELETE CASCADE,
+	subject text not null,
+	role text not null,
+	created_at datetime not null default CURRENT_TIMESTAMP,
+	PRIMARY KEY (list_id, subject)
+);
+
+CREATE INDEX list_members_subject ON list_members (subject);
+
+ALTER TABLE todo ADD COLUMN list_id integer;
+
+CREATE INDEX todo_list_id ON todo (list_id, deleted_at);
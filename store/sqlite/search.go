@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/sqlstore"
+	"math"
+	"strings"
+)
+
+// The search index is an FTS4 table, which go-sqlite3 compiles into every
+// build, FTS5 would need the sqlite_fts5 build tag. FTS4 has no bm25, it is
+// registered as todo_bm25 on the connections of DriverName.
+
+// Column weights for bm25, a match in the name counts more than one in the tags or description
+var searchWeights = []float64{10.0, 2.0, 5.0}
+
+// bm25 parameters, the defaults of FTS5
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// ftsQuery turns free text into an FTS4 query where every word must match
+// as a prefix. Words are quoted so FTS4 operators in user input are literal.
+func ftsQuery(q string) string {
+	words := todo.TodoSearchQuery{Q: q}.Terms()
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, `"`+word+`*"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// bm25 scores a row from its matchinfo(todo_fts, 'pcnalx') blob like the bm25
+// function of FTS5, higher is better. FTS4 only counts the rows a phrase hits
+// per column, the highest of those counts stands for the rows it hits at all.
+func bm25(info []byte, weights []float64) float64 {
+	v := make([]float64, len(info)/4)
+	for i := range v {
+		v[i] = float64(binary.NativeEndian.Uint32(info[i*4:]))
+	}
+	if len(v) < 3 {
+		return 0
+	}
+	phrases, columns, rows := int(v[0]), int(v[1]), v[2]
+	if len(v) < 3+2*columns+3*phrases*columns {
+		return 0
+	}
+	var avgLength, length float64
+	for c := 0; c < columns; c++ {
+		avgLength += v[3+c]
+		length += v[3+columns+c]
+	}
+	if avgLength == 0 {
+		return 0
+	}
+	hits := v[3+2*columns:]
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		var freq, rowsHit float64
+		for c := 0; c < columns && c < len(weights); c++ {
+			x := hits[3*(p*columns+c):]
+			freq += weights[c] * x[0]
+			rowsHit = math.Max(rowsHit, x[2])
+		}
+		idf := math.Log((rows - rowsHit + 0.5) / (rowsHit + 0.5))
+		if idf <= 0 {
+			idf = 1e-6
+		}
+		score += idf * freq * (bm25K1 + 1) / (freq + bm25K1*(1-bm25B+bm25B*length/avgLength))
+	}
+	return score
+}
+
+type searchRow struct {
+	sqlstore.TodoRow
+	Rank                 float64 `db:"rank"`
+	NameHighlight        string  `db:"name_highlight"`
+	DescriptionHighlight string  `db:"description_highlight"`
+}
+
+// SearchTodoTasks returns the tasks matching query.Q ranked by bm25, best match first
+func (s *StoreSvc) SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error) {
+	response := todo.TodoSearchResponse{Items: []todo.TodoSearchResult{}}
+	match := ftsQuery(query.Q)
+	if match == "" {
+		return response, nil
+	}
+
+	where := []string{"todo_fts MATCH ?", "t.deleted_at IS NULL"}
+	args := []interface{}{match}
+	if clause, scopeArgs := sqlstore.ScopeClause(ctx, "t."); clause != "" {
+		where = append(where, clause)
+		args = append(args, scopeArgs...)
+	}
+	if query.Completed != nil {
+		where = append(where, "t.completed = ?")
+		args = append(args, *query.Completed)
+	}
+	args = append(args, query.Limit)
+
+	searchSQL := `SELECT t.` + strings.ReplaceAll(sqlstore.TodoColumns, ", ", ", t.") + `,
+		todo_bm25(matchinfo(todo_fts, 'pcnalx')) AS rank,
+		snippet(todo_fts, '<mark>', '</mark>', '…', 0, 64) AS name_highlight,
+		snippet(todo_fts, '<mark>', '</mark>', '…', 1, 16) AS description_highlight
+		FROM todo_fts JOIN todo t ON t.id = todo_fts.docid
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY rank DESC, t.id LIMIT ?`
+
+	var rows []searchRow
+	queryCtx, span := s.StartQuery(ctx, "todo_fts", searchSQL)
+	err := s.DB().SelectContext(queryCtx, &rows, searchSQL, args...)
+	sqlstore.EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return todo.TodoSearchResponse{}, s.Error(ctx, err)
+	}
+	for _, row := range rows {
+		response.Items = append(response.Items, todo.TodoSearchResult{
+			TodoResponse: row.Response(ctx),
+			Rank:         row.Rank,
+			Highlight: todo.TodoHighlight{
+				Name:        row.NameHighlight,
+				Description: row.DescriptionHighlight,
+			},
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"strings"
+	"testing"
+)
+
+func TestFtsQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"milk", `"milk*"`},
+		{"buy  milk", `"buy*" "milk*"`},
+		{`milk" OR name:*`, `"milk*" "OR*" "name*"`},
+		{"--", ``},
+	}
+	for _, tt := range tests {
+		if got := ftsQuery(tt.q); got != tt.want {
+			t.Errorf("ftsQuery(%q) = %v, want %v\n", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestStoreSvc_SearchTodoTasks(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	inputs := []todo.TodoRequestInput{
+		{Name: "Buy milk", Description: "semi skimmed from the corner shop"},
+		{Name: "Walk the dog", Description: "remember to buy dog food on the way"},
+		{Name: "Café visit", Tags: []string{"errands"}},
+		{Name: "Pay rent", Completed: true, Tags: []string{"bills"}},
+	}
+	for _, input := range inputs {
+		input.Priority = todo.PriorityMedium
+		if _, err := s.CreateTodoTask(ctx, input); err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+	// Renamed tasks must be found by their new name only
+	_, err := s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: 4, Name: "Pay electricity", Completed: true, Priority: todo.PriorityMedium, Tags: []string{"bills"}})
+	if err != nil {
+		t.Fatalf("UpdateTodoTaskByID() err = %v\n", err)
+	}
+	completed := true
+
+	tests := []struct {
+		name  string
+		query todo.TodoSearchQuery
+		want  []int
+	}{
+		{"name ranks above description", todo.TodoSearchQuery{Q: "buy"}, []int{1, 2}},
+		{"prefix", todo.TodoSearchQuery{Q: "wal"}, []int{2}},
+		{"all words must match", todo.TodoSearchQuery{Q: "dog food"}, []int{2}},
+		{"diacritics are ignored", todo.TodoSearchQuery{Q: "cafe"}, []int{3}},
+		{"tags", todo.TodoSearchQuery{Q: "errands"}, []int{3}},
+		{"updated name", todo.TodoSearchQuery{Q: "electricity"}, []int{4}},
+		{"old name", todo.TodoSearchQuery{Q: "rent"}, nil},
+		{"completed filter", todo.TodoSearchQuery{Q: "pay buy", Completed: &completed}, nil},
+		{"operators are literal", todo.TodoSearchQuery{Q: `milk" OR "dog`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.Limit = todo.DefaultSearchLimit
+			response, err := s.SearchTodoTasks(ctx, query)
+			if err != nil {
+				t.Fatalf("SearchTodoTasks() err = %v\n", err)
+			}
+			var got []int
+			for _, item := range response.Items {
+				got = append(got, item.Id)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("SearchTodoTasks() ids = %v, want %v\n", got, tt.want)
+			}
+		})
+	}
+
+	response, _ := s.SearchTodoTasks(ctx, todo.TodoSearchQuery{Q: "milk", Limit: 1})
+	if len(response.Items) != 1 || !strings.Contains(response.Items[0].Highlight.Name, "<mark>milk</mark>") {
+		t.Errorf("SearchTodoTasks() highlight = %+v, want milk marked\n", response.Items)
+	}
+	// Tasks are ranked before the limit applies
+	response, _ = s.SearchTodoTasks(ctx, todo.TodoSearchQuery{Q: "buy", Limit: 1})
+	if len(response.Items) != 1 || response.Items[0].Id != 1 || response.Items[0].Rank <= 0 {
+		t.Errorf("SearchTodoTasks() with limit 1 = %+v, want task 1 with a positive rank\n", response.Items)
+	}
+
+	// Tasks of other owners are not found
+	alice := auth.NewContext(ctx, auth.Principal{Subject: "alice"})
+	if response, _ := s.SearchTodoTasks(alice, todo.TodoSearchQuery{Q: "milk", Limit: 1}); len(response.Items) != 0 {
+		t.Errorf("SearchTodoTasks() of alice = %+v, want none\n", response.Items)
+	}
+
+	// unless they are in a list alice is a member of
+	list, err := s.CreateList(ctx, todo.ListRequestInput{Name: "home"}, "alice")
+	if err != nil {
+		t.Fatalf("CreateList() err = %v\n", err)
+	}
+	todos, err := s.GetTodoTaskByID(ctx, []int{1})
+	if err != nil {
+		t.Fatalf("GetTodoTaskByID() err = %v\n", err)
+	}
+	input := todos[0].RequestInput()
+	input.ListId = list.Id
+	if _, err := s.UpdateTodoTaskByID(ctx, input); err != nil {
+		t.Fatalf("UpdateTodoTaskByID() err = %v\n", err)
+	}
+	member := store.NewContext(alice, store.Scope{Owner: "alice", Lists: map[int]string{list.Id: todo.ListRoleViewer}})
+	if response, _ := s.SearchTodoTasks(member, todo.TodoSearchQuery{Q: "milk", Limit: 1}); len(response.Items) != 1 || response.Items[0].Id != 1 {
+		t.Errorf("SearchTodoTasks() of list member = %+v, want task 1\n", response.Items)
+	}
+
+	// Deleted tasks leave the index
+	if _, err := s.DeleteTodoTaskByID(ctx, []int{1}, 0); err != nil {
+		t.Fatalf("DeleteTodoTaskByID() err = %v\n", err)
+	}
+	response, _ = s.SearchTodoTasks(ctx, todo.TodoSearchQuery{Q: "milk", Limit: 1})
+	if len(response.Items) != 0 {
+		t.Errorf("SearchTodoTasks() after delete = %+v, want none\n", response.Items)
+	}
+}
@@ -1,149 +1,104 @@
 package sqlite
 
 import (
-	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store/sqlstore"
 	"github.com/jmoiron/sqlx"
-	"log"
+	"github.com/mattn/go-sqlite3"
+	"log/slog"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
-type StoreSvc struct {
-	db *sqlx.DB
+// dialect compares timestamps through julianday, so values written with and
+// without fractional seconds or zone offsets order correctly. LIKE is
+// already case-insensitive for ASCII in SQLite.
+var dialect = sqlstore.Dialect{
+	System: "sqlite",
+	Time:   func(expr string) string { return "julianday(" + expr + ")" },
+	Text:   func(expr string) string { return expr },
+	Like:   "LIKE",
+	Error:  storeError,
 }
 
-func New(db *sqlx.DB) *StoreSvc {
-	store := &StoreSvc{
-		db: db,
-	}
-	return store
+// DriverName is the database/sql driver to open SQLite databases with, it is
+// go-sqlite3 with the functions the search queries call
+const DriverName = "sqlite3_todo"
+
+func init() {
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("todo_bm25", func(info []byte) float64 {
+				return bm25(info, searchWeights)
+			}, true)
+		},
+	})
 }
 
-func (s *StoreSvc) GetTaskList() []todo.TodoResponse {
-
-	queryDataSQL := "SELECT id, name, completed FROM todo"
-	rows, err := s.db.Query(queryDataSQL)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer rows.Close()
-
-	var todos []todo.TodoResponse
-
-	for rows.Next() {
-		var todo todo.TodoResponse
-		err := rows.Scan(&todo.Id, &todo.Name, &todo.Completed)
-		if err != nil {
-			log.Fatal(err)
-		}
-		todos = append(todos, todo)
-	}
-	return todos
+// Options are the connection settings encoded in a SQLite DSN
+type Options struct {
+	// BusyTimeout is how long a connection waits for a lock before failing
+	BusyTimeout time.Duration
+	// JournalMode and Synchronous set the pragmas of the same name
+	JournalMode string
+	Synchronous string
 }
 
-func (s *StoreSvc) CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
-	_, err := s.db.Exec("PRAGMA journal_mode = WAL")
-	tx, err := s.db.Begin()
-	if err != nil {
-		log.Fatal(err)
+// DSN returns the data source name opening the database file at path
+// with options applied to every connection
+func DSN(path string, options Options) string {
+	params := url.Values{}
+	if options.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(options.BusyTimeout.Milliseconds(), 10))
 	}
-	stmt, err := tx.Prepare(`insert into todo(id, name,completed) values(?, ?,?)`)
-	if err != nil {
-		log.Fatal(err)
+	if options.JournalMode != "" {
+		params.Set("_journal_mode", strings.ToUpper(options.JournalMode))
 	}
-	defer stmt.Close()
-	defer tx.Rollback()
-	_, err = stmt.Exec(requestInput.Id, requestInput.Name, requestInput.Completed)
-	if err != nil {
-		log.Fatal(err)
+	if options.Synchronous != "" {
+		params.Set("_synchronous", strings.ToUpper(options.Synchronous))
 	}
-	err = tx.Commit()
-	if err != nil {
-		log.Fatal(err)
+	dsn := "file:" + path
+	if len(params) > 0 {
+		dsn += "?" + params.Encode()
 	}
-	return s.GetTodoTaskByID(ctx, []int{requestInput.Id})[0], nil
-
+	return dsn
 }
 
-func (s *StoreSvc) GetTodoTaskByID(ctx context.Context, ids []int) []todo.TodoResponse {
-
-	tx, err := s.db.Begin()
-	if err != nil {
-		log.Fatal(err)
-	}
-	if len(ids) == 0 {
-		return s.GetTaskList()
-	}
-
-	placeholders := make([]string, len(ids))
-	args := make([]interface{}, len(ids))
-	for i, id := range ids {
-		placeholders[i] = "?"
-		args[i] = id
-	}
-
-	// Construct the SQL query with the IN clause and placeholders.
-	queryDataSQL := fmt.Sprintf("SELECT id, name, completed FROM todo WHERE id IN (%s)", strings.Join(placeholders, ","))
-
-	// Query tasks from the 'todo' table with dynamic-length IDs.
-	rows, err := tx.Query(queryDataSQL, args...)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer rows.Close()
-
-	var todos []todo.TodoResponse
+type StoreSvc struct {
+	*sqlstore.Store
+}
 
-	for rows.Next() {
-		var todo todo.TodoResponse
-		err := rows.Scan(&todo.Id, &todo.Name, &todo.Completed)
-		if err != nil {
-			log.Fatal(err)
-		}
-		todos = append(todos, todo)
+func New(db *sqlx.DB) *StoreSvc {
+	store := &StoreSvc{
+		Store: sqlstore.New(db, dialect),
 	}
-
-	return todos
+	return store
 }
 
-func (s *StoreSvc) DeleteTodoTaskByID(ctx context.Context, id []int) todo.TodoResponse {
-	s.db.Exec("PRAGMA journal_mode = WAL")
-	deleteDataSQL := "DELETE FROM todo WHERE id = ?"
-	for _, taskID := range id {
-		_, err := s.db.Exec(deleteDataSQL, taskID)
-		if err != nil {
-			log.Printf("Error deleting task with ID %d: %v\n", taskID, err)
-		} else {
-			fmt.Printf("Task with ID %d deleted successfully.\n", taskID)
+// storeError converts a driver error into one of the todo error kinds
+func storeError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code {
+		case sqlite3.ErrConstraint:
+			return fmt.Errorf("%w: %v", todo.ErrConflict, err)
+		case sqlite3.ErrBusy, sqlite3.ErrLocked:
+			return fmt.Errorf("%w: %v", todo.ErrUnavailable, err)
 		}
 	}
-	return todo.TodoResponse{Message: "Success"}
+	return fmt.Errorf("%w: %v", todo.ErrInternal, err)
 }
 
-func (s *StoreSvc) UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) todo.TodoResponse {
-	s.db.Exec("PRAGMA journal_mode = WAL")
-	updateDataSQL := "UPDATE todo SET name = ?, completed = ? WHERE id = ?"
-	result, err := s.db.Exec(updateDataSQL, requestInput.Name, requestInput.Completed, requestInput.Id)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Check the number of rows affected by the update.
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if rowsAffected > 0 {
-		fmt.Printf("Task with ID %d updated successfully.\n", requestInput.Id)
-	} else {
-		fmt.Printf("No task found with ID %d.\n", requestInput.Id)
-	}
-	return todo.TodoResponse{
-		Message:   "Success",
-		Id:        requestInput.Id,
-		Name:      requestInput.Name,
-		Completed: requestInput.Completed,
+// Close checkpoints the write-ahead log into the database file, so the file
+// is complete on its own, and closes the database
+func (s *StoreSvc) Close() error {
+	if _, err := s.DB().Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		slog.Error("checkpointing the write-ahead log failed", "error", err)
 	}
+	return s.Store.Close()
 }
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/RanbirSingh-Velotio/todo-service/store/migrate"
+	"github.com/RanbirSingh-Velotio/todo-service/store/storetest"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"sort"
+	"testing"
+	"time"
+)
+
+// newTestStore returns a store backed by a migrated in-memory database
+func newTestStore(t *testing.T) *StoreSvc {
+	db, err := sqlx.Connect(DriverName, ":memory:")
+	if err != nil {
+		t.Fatalf("sqlx.Connect() err = %v\n", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrate.New(db, Migrations())
+	if err != nil {
+		t.Fatalf("migrate.New() err = %v\n", err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatalf("Up() err = %v\n", err)
+	}
+	return New(db)
+}
+
+func TestStoreSvc(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.StoreSvc {
+		return newTestStore(t)
+	})
+}
+
+func TestStoreSvc_ListTodoTasks_SameTimestamp(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	// Rows migrated from the first release share one CURRENT_TIMESTAMP value
+	for i := 0; i < 5; i++ {
+		s.DB().MustExec("INSERT INTO todo (name) VALUES (?)", fmt.Sprintf("legacy %d", i))
+	}
+
+	query := todo.TodoQuery{Sort: "created_at", Limit: 2}
+	var got []int
+	for page := 0; page < 10; page++ {
+		response, err := s.ListTodoTasks(ctx, query)
+		if err != nil {
+			t.Fatalf("ListTodoTasks() err = %v\n", err)
+		}
+		for _, item := range response.Items {
+			got = append(got, item.Id)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		query.Cursor = response.NextCursor
+	}
+	if len(got) != 5 || !sort.IntsAreSorted(got) {
+		t.Errorf("ListTodoTasks() ids = %v, want 5 ids in order\n", got)
+	}
+}
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		path    string
+		options Options
+		want    string
+	}{
+		{"todo.db", Options{}, "file:todo.db"},
+		{"/var/lib/todo.db", Options{BusyTimeout: 5 * time.Second, JournalMode: "wal", Synchronous: "normal"},
+			"file:/var/lib/todo.db?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL"},
+	}
+	for _, tt := range tests {
+		if got := DSN(tt.path, tt.options); got != tt.want {
+			t.Errorf("DSN(%q) = %v, want %v\n", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestStoreSvc_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t)
+	hash := auth.HashAPIKey("secret")
+	if err := s.CreateAPIKey(ctx, hash, "ci", []string{"admin"}); err != nil {
+		t.Fatalf("CreateAPIKey() err = %v\n", err)
+	}
+	if err := s.CreateAPIKey(ctx, hash, "ci", nil); !errors.Is(err, todo.ErrConflict) {
+		t.Errorf("CreateAPIKey() duplicate err = %v, want ErrConflict\n", err)
+	}
+
+	p, err := s.LookupAPIKey(ctx, hash)
+	if err != nil || p.Subject != "ci" || !p.HasRole("admin") {
+		t.Errorf("LookupAPIKey() = %+v, %v, want ci with role admin\n", p, err)
+	}
+	if _, err := s.LookupAPIKey(ctx, auth.HashAPIKey("other")); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("LookupAPIKey() unknown err = %v, want ErrUnauthenticated\n", err)
+	}
+
+	if revoked, err := s.RevokeAPIKeys(ctx, "ci"); revoked != 1 || err != nil {
+		t.Errorf("RevokeAPIKeys() = %v, %v, want 1\n", revoked, err)
+	}
+	if _, err := s.LookupAPIKey(ctx, hash); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Errorf("LookupAPIKey() revoked err = %v, want ErrUnauthenticated\n", err)
+	}
+}
+
+func TestStoreSvc_QuerySpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	ctx := context.Background()
+	s := newTestStore(t)
+	list, err := s.CreateList(ctx, todo.ListRequestInput{Name: "home"}, "alice")
+	if err != nil {
+		t.Fatalf("CreateList() err = %v\n", err)
+	}
+	if _, err := s.ListRoles(ctx, "alice"); err != nil {
+		t.Fatalf("ListRoles() err = %v\n", err)
+	}
+	if _, err := s.LookupAPIKey(ctx, auth.HashAPIKey("secret")); !errors.Is(err, auth.ErrUnauthenticated) {
+		t.Fatalf("LookupAPIKey() err = %v\n", err)
+	}
+	if _, err := s.GetTodoTaskByID(ctx, []int{list.Id}); !errors.Is(err, todo.ErrNotFound) {
+		t.Fatalf("GetTodoTaskByID() err = %v\n", err)
+	}
+
+	tables := map[string]string{}
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.sql.table" {
+				tables[span.Name()] = attr.Value.AsString()
+			}
+		}
+	}
+	for name, table := range map[string]string{
+		"INSERT lists":        "lists",
+		"INSERT list_members": "list_members",
+		"SELECT list_members": "list_members",
+		"SELECT api_keys":     "api_keys",
+		"SELECT todo":         "todo",
+	} {
+		if tables[name] != table {
+			t.Errorf("span %q db.sql.table = %q, want %q (spans %v)\n", name, tables[name], table, tables)
+		}
+	}
+}
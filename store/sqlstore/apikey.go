@@ -0,0 +1,50 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"strings"
+	"time"
+)
+
+// LookupAPIKey returns the principal of the unrevoked key of the api_keys
+// table hashed to hash, it makes Store an auth.KeyStore
+func (s *Store) LookupAPIKey(ctx context.Context, hash string) (auth.Principal, error) {
+	var row struct {
+		Subject string `db:"subject"`
+		Roles   string `db:"roles"`
+	}
+	selectSQL := "SELECT subject, roles FROM api_keys WHERE key_hash = ? AND revoked_at IS NULL"
+	queryCtx, span := s.StartQuery(ctx, "api_keys", selectSQL)
+	err := s.conn.QueryRowxContext(queryCtx, s.conn.Rebind(selectSQL), hash).StructScan(&row)
+	EndQuery(span, 1, err)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.Principal{}, fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+	}
+	if err != nil {
+		return auth.Principal{}, s.Error(ctx, err)
+	}
+	return auth.Principal{Subject: row.Subject, Roles: auth.ParseRoles(row.Roles)}, nil
+}
+
+// CreateAPIKey stores the key hashed to hash as issued to subject with roles
+func (s *Store) CreateAPIKey(ctx context.Context, hash, subject string, roles []string) error {
+	insertSQL := "INSERT INTO api_keys (key_hash, subject, roles, created_at) VALUES (?, ?, ?, ?)"
+	if _, err := s.exec(ctx, "api_keys", insertSQL, hash, subject, strings.Join(roles, ","), time.Now().UTC()); err != nil {
+		return s.Error(ctx, err)
+	}
+	return nil
+}
+
+// RevokeAPIKeys revokes every key issued to subject and returns how many
+func (s *Store) RevokeAPIKeys(ctx context.Context, subject string) (int, error) {
+	updateSQL := "UPDATE api_keys SET revoked_at = ? WHERE subject = ? AND revoked_at IS NULL"
+	revoked, err := s.exec(ctx, "api_keys", updateSQL, time.Now().UTC(), subject)
+	if err != nil {
+		return 0, s.Error(ctx, err)
+	}
+	return int(revoked), nil
+}
@@ -0,0 +1,274 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+// listColumns lists the columns scanned into listRow
+const listColumns = "id, name, description, created_at, updated_at"
+
+// listRow is a row of the lists table
+type listRow struct {
+	Id          int       `db:"id"`
+	Name        string    `db:"name"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+// memberRow is a row of the list_members table
+type memberRow struct {
+	ListId  int    `db:"list_id"`
+	Subject string `db:"subject"`
+	Role    string `db:"role"`
+}
+
+// transaction runs fn with a store running its queries in one transaction,
+// which is committed when fn returns no error
+func (s *Store) transaction(ctx context.Context, fn func(tx *Store) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return s.Error(ctx, err)
+	}
+	// Rolling back after a commit does nothing
+	defer tx.Rollback()
+	if err := fn(&Store{db: s.db, conn: tx, dialect: s.dialect}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return s.Error(ctx, err)
+	}
+	return nil
+}
+
+// selectLists runs query and returns the resulting lists along with their members
+func (s *Store) selectLists(ctx context.Context, query string, args ...interface{}) ([]todo.ListResponse, error) {
+	var rows []listRow
+	queryCtx, span := s.StartQuery(ctx, "lists", query)
+	err := sqlx.SelectContext(queryCtx, s.conn, &rows, s.conn.Rebind(query), args...)
+	EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return nil, s.Error(ctx, err)
+	}
+	lists := make([]todo.ListResponse, 0, len(rows))
+	if len(rows) == 0 {
+		return lists, nil
+	}
+
+	ids := make([]int, len(rows))
+	for i, row := range rows {
+		ids[i] = row.Id
+	}
+	clause, memberArgs := inClause("list_id", ids)
+	membersSQL := "SELECT list_id, subject, role FROM list_members WHERE " + clause + " ORDER BY list_id, subject"
+	var members []memberRow
+	queryCtx, span = s.StartQuery(ctx, "list_members", membersSQL)
+	err = sqlx.SelectContext(queryCtx, s.conn, &members, s.conn.Rebind(membersSQL), memberArgs...)
+	EndQuery(span, int64(len(members)), err)
+	if err != nil {
+		return nil, s.Error(ctx, err)
+	}
+	byList := map[int][]todo.ListMember{}
+	for _, member := range members {
+		byList[member.ListId] = append(byList[member.ListId], todo.ListMember{Subject: member.Subject, Role: member.Role})
+	}
+
+	for _, row := range rows {
+		createdAt, updatedAt := row.CreatedAt.UTC(), row.UpdatedAt.UTC()
+		list := todo.ListResponse{
+			Id:          row.Id,
+			Name:        row.Name,
+			Description: row.Description,
+			CreatedAt:   &createdAt,
+			UpdatedAt:   &updatedAt,
+			Members:     byList[row.Id],
+		}
+		if list.Members == nil {
+			list.Members = []todo.ListMember{}
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// touchList bumps the updated_at of the list with id, it fails with
+// ErrNotFound when there is no such list
+func (s *Store) touchList(ctx context.Context, id int, now time.Time) error {
+	rowsAffected, err := s.exec(ctx, "lists", "UPDATE lists SET updated_at = ? WHERE id = ?", now, id)
+	if err != nil {
+		return s.Error(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	}
+	return nil
+}
+
+func (s *Store) CreateList(ctx context.Context, input todo.ListRequestInput, owner string) (todo.ListResponse, error) {
+	var id int
+	err := s.transaction(ctx, func(tx *Store) error {
+		insertSQL := "INSERT INTO lists (name, description, created_at, updated_at) VALUES (?, ?, ?, ?) RETURNING id"
+		now := time.Now().UTC()
+		queryCtx, span := tx.StartQuery(ctx, "lists", insertSQL)
+		err := tx.conn.QueryRowxContext(queryCtx, tx.conn.Rebind(insertSQL), input.Name, input.Description, now, now).Scan(&id)
+		EndQuery(span, 1, err)
+		if err != nil {
+			return tx.Error(ctx, err)
+		}
+		if owner == "" {
+			return nil
+		}
+		return tx.putMember(ctx, id, todo.ListMember{Subject: owner, Role: todo.ListRoleOwner}, now)
+	})
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	return s.GetList(ctx, id)
+}
+
+func (s *Store) GetList(ctx context.Context, id int) (todo.ListResponse, error) {
+	lists, err := s.selectLists(ctx, "SELECT "+listColumns+" FROM lists WHERE id = ?", id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	if len(lists) == 0 {
+		return todo.ListResponse{}, fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+	}
+	return lists[0], nil
+}
+
+// ListLists returns the lists member is a member of, or every list when
+// member is empty, ordered by id
+func (s *Store) ListLists(ctx context.Context, member string) ([]todo.ListResponse, error) {
+	if member == "" {
+		return s.selectLists(ctx, "SELECT "+listColumns+" FROM lists ORDER BY id")
+	}
+	return s.selectLists(ctx, "SELECT "+listColumns+" FROM lists WHERE id IN (SELECT list_id FROM list_members WHERE subject = ?) ORDER BY id", member)
+}
+
+func (s *Store) UpdateList(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error) {
+	updateSQL := "UPDATE lists SET name = ?, description = ?, updated_at = ? WHERE id = ?"
+	rowsAffected, err := s.exec(ctx, "lists", updateSQL, input.Name, input.Description, time.Now().UTC(), input.Id)
+	if err != nil {
+		return todo.ListResponse{}, s.Error(ctx, err)
+	}
+	if rowsAffected == 0 {
+		return todo.ListResponse{}, fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, input.Id)
+	}
+	list, err := s.GetList(ctx, input.Id)
+	if err != nil {
+		return todo.ListResponse{}, err
+	}
+	list.Message = "Success"
+	return list, nil
+}
+
+// DeleteList deletes the list with id and its members in one transaction.
+// Its tasks, live and in the trash, leave the list and stay with their owners.
+func (s *Store) DeleteList(ctx context.Context, id int) error {
+	return s.transaction(ctx, func(tx *Store) error {
+		rowsAffected, err := tx.exec(ctx, "lists", "DELETE FROM lists WHERE id = ?", id)
+		if err != nil {
+			return tx.Error(ctx, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: no list found with id %d", todo.ErrNotFound, id)
+		}
+		if _, err := tx.exec(ctx, "list_members", "DELETE FROM list_members WHERE list_id = ?", id); err != nil {
+			return tx.Error(ctx, err)
+		}
+		detachSQL := "UPDATE todo SET list_id = NULL, updated_at = ?, version = version + 1 WHERE list_id = ?"
+		if _, err := tx.exec(ctx, "todo", detachSQL, time.Now().UTC(), id); err != nil {
+			return tx.Error(ctx, err)
+		}
+		return nil
+	})
+}
+
+// putMember adds member to the list with id, or changes its role when it
+// already is a member
+func (s *Store) putMember(ctx context.Context, id int, member todo.ListMember, now time.Time) error {
+	upsertSQL := `INSERT INTO list_members (list_id, subject, role, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (list_id, subject) DO UPDATE SET role = excluded.role`
+	if _, err := s.exec(ctx, "list_members", upsertSQL, id, member.Subject, member.Role, now); err != nil {
+		return s.Error(ctx, err)
+	}
+	return nil
+}
+
+// checkLastOwner fails with ErrConflict when subject is the only owner of
+// the list with id
+func (s *Store) checkLastOwner(ctx context.Context, id int, subject string) error {
+	var owners []string
+	selectSQL := "SELECT subject FROM list_members WHERE list_id = ? AND role = ?"
+	queryCtx, span := s.StartQuery(ctx, "list_members", selectSQL)
+	err := sqlx.SelectContext(queryCtx, s.conn, &owners, s.conn.Rebind(selectSQL), id, todo.ListRoleOwner)
+	EndQuery(span, int64(len(owners)), err)
+	if err != nil {
+		return s.Error(ctx, err)
+	}
+	if len(owners) == 1 && owners[0] == subject {
+		return fmt.Errorf("%w: %s is the last owner of list %d", todo.ErrConflict, subject, id)
+	}
+	return nil
+}
+
+// PutListMember adds member to the list with id, or changes its role when
+// it already is a member. The list is touched first, which locks its row
+// until the transaction ends, so concurrent changes cannot both remove the
+// last owner.
+func (s *Store) PutListMember(ctx context.Context, id int, member todo.ListMember) error {
+	return s.transaction(ctx, func(tx *Store) error {
+		now := time.Now().UTC()
+		if err := tx.touchList(ctx, id, now); err != nil {
+			return err
+		}
+		if member.Role != todo.ListRoleOwner {
+			if err := tx.checkLastOwner(ctx, id, member.Subject); err != nil {
+				return err
+			}
+		}
+		return tx.putMember(ctx, id, member, now)
+	})
+}
+
+// DeleteListMember removes subject from the list with id, locking the list
+// like PutListMember
+func (s *Store) DeleteListMember(ctx context.Context, id int, subject string) error {
+	return s.transaction(ctx, func(tx *Store) error {
+		if err := tx.touchList(ctx, id, time.Now().UTC()); err != nil {
+			return err
+		}
+		if err := tx.checkLastOwner(ctx, id, subject); err != nil {
+			return err
+		}
+		rowsAffected, err := tx.exec(ctx, "list_members", "DELETE FROM list_members WHERE list_id = ? AND subject = ?", id, subject)
+		if err != nil {
+			return tx.Error(ctx, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: %s is not a member of list %d", todo.ErrNotFound, subject, id)
+		}
+		return nil
+	})
+}
+
+func (s *Store) ListRoles(ctx context.Context, subject string) (map[int]string, error) {
+	var rows []memberRow
+	selectSQL := "SELECT list_id, subject, role FROM list_members WHERE subject = ?"
+	queryCtx, span := s.StartQuery(ctx, "list_members", selectSQL)
+	err := sqlx.SelectContext(queryCtx, s.conn, &rows, s.conn.Rebind(selectSQL), subject)
+	EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return nil, s.Error(ctx, err)
+	}
+	roles := make(map[int]string, len(rows))
+	for _, row := range rows {
+		roles[row.ListId] = row.Role
+	}
+	return roles, nil
+}
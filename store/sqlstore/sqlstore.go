@@ -0,0 +1,614 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/logging"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/tracing"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"time"
+)
+
+// TodoColumns lists the columns scanned into TodoRow
+const TodoColumns = "id, name, description, completed, due_at, priority, tags, created_at, updated_at, completed_at, deleted_at, version, owner_id, list_id"
+
+// Dialect holds what differs between the SQL databases a Store runs on
+type Dialect struct {
+	// System names the database in spans, e.g. sqlite or postgresql
+	System string
+	// Time returns the expression a timestamp column or placeholder is compared by
+	Time func(expr string) string
+	// Text returns the expression a text column is sorted by
+	Text func(expr string) string
+	// Like is the case-insensitive LIKE operator
+	Like string
+	// Error converts a driver error into one of the todo error kinds
+	Error func(err error) error
+}
+
+// Store implements the todo store methods shared by the SQL backends,
+// queries are written with ? placeholders and rebound for the driver
+type Store struct {
+	db *sqlx.DB
+	// conn runs the queries, it is db or the transaction of a batch
+	conn    sqlx.ExtContext
+	dialect Dialect
+}
+
+// TodoRow is a row of the todo table
+type TodoRow struct {
+	Id          int           `db:"id"`
+	Name        string        `db:"name"`
+	Description string        `db:"description"`
+	Completed   bool          `db:"completed"`
+	DueAt       sql.NullTime  `db:"due_at"`
+	Priority    string        `db:"priority"`
+	Tags        string        `db:"tags"`
+	CreatedAt   time.Time     `db:"created_at"`
+	UpdatedAt   time.Time     `db:"updated_at"`
+	CompletedAt sql.NullTime  `db:"completed_at"`
+	DeletedAt   sql.NullTime  `db:"deleted_at"`
+	Version     int           `db:"version"`
+	OwnerId     string        `db:"owner_id"`
+	ListId      sql.NullInt64 `db:"list_id"`
+}
+
+// Response converts the row to the task returned by the store
+func (r TodoRow) Response(ctx context.Context) todo.TodoResponse {
+	var tags []string
+	if err := json.Unmarshal([]byte(r.Tags), &tags); err != nil {
+		logging.FromContext(ctx).Warn("invalid tags", "id", r.Id, "error", err)
+	}
+	createdAt, updatedAt := r.CreatedAt.UTC(), r.UpdatedAt.UTC()
+	return todo.TodoResponse{
+		Id:          r.Id,
+		Name:        r.Name,
+		Description: r.Description,
+		Completed:   r.Completed,
+		DueAt:       nullTime(r.DueAt),
+		Priority:    r.Priority,
+		Tags:        tags,
+		CreatedAt:   &createdAt,
+		UpdatedAt:   &updatedAt,
+		CompletedAt: nullTime(r.CompletedAt),
+		DeletedAt:   nullTime(r.DeletedAt),
+		Version:     r.Version,
+		OwnerId:     r.OwnerId,
+		ListId:      int(r.ListId.Int64),
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// utcTime converts an optional timestamp to UTC so stored values sort correctly
+func utcTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// nullID stores the id 0 as NULL
+func nullID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func encodeTags(tags []string) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	data, _ := json.Marshal(tags)
+	return string(data)
+}
+
+func New(db *sqlx.DB, dialect Dialect) *Store {
+	store := &Store{
+		db:      db,
+		conn:    db,
+		dialect: dialect,
+	}
+	return store
+}
+
+// DB returns the database the store runs its queries on
+func (s *Store) DB() *sqlx.DB {
+	return s.db
+}
+
+// Ping checks the database can still be reached
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database once the queries already running finish
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Error converts err into one of the todo error kinds. Queries interrupted
+// because ctx is done return the context error so callers can tell an
+// expired deadline from a failed query.
+func (s *Store) Error(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%w: %v", ctxErr, err)
+	}
+	return s.dialect.Error(err)
+}
+
+// dbTableKey is the span attribute naming the table a statement runs on
+const dbTableKey = attribute.Key("db.sql.table")
+
+// StartQuery starts the span of statement on table, named after its
+// operation and table and annotated with the statement text. Values are
+// passed as placeholders so the text never carries user data. Statements
+// on no table, such as savepoints, pass an empty table.
+func (s *Store) StartQuery(ctx context.Context, table, statement string) (context.Context, trace.Span) {
+	operation := strings.ToUpper(strings.Fields(statement)[0])
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemKey.String(s.dialect.System),
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(statement),
+	}
+	name := operation
+	if table != "" {
+		name += " " + table
+		attrs = append(attrs, dbTableKey.String(table))
+	}
+	return tracing.Start(ctx, name, attrs...)
+}
+
+// EndQuery ends the span of a statement that returned or changed rows rows
+func EndQuery(span trace.Span, rows int64, err error) {
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows", rows))
+	}
+	tracing.End(span, err)
+}
+
+// selectTodos runs query and converts the resulting rows to responses
+func (s *Store) selectTodos(ctx context.Context, query string, args ...interface{}) ([]todo.TodoResponse, error) {
+	var rows []TodoRow
+	start := time.Now()
+	queryCtx, span := s.StartQuery(ctx, "todo", query)
+	err := sqlx.SelectContext(queryCtx, s.conn, &rows, s.conn.Rebind(query), args...)
+	EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return nil, s.Error(ctx, err)
+	}
+	logging.FromContext(ctx).Debug("store query", "sql", query, "rows", len(rows), "duration_ms", time.Since(start).Milliseconds())
+
+	todos := make([]todo.TodoResponse, 0, len(rows))
+	for _, row := range rows {
+		todos = append(todos, row.Response(ctx))
+	}
+	return todos, nil
+}
+
+// sortColumn returns the expression rows are ordered by for a sort field.
+// Timestamps are compared through the dialect so values written with and
+// without fractional seconds or zone offsets order correctly.
+func (s *Store) sortColumn(field string) string {
+	switch field {
+	case todo.SortName:
+		return s.dialect.Text("name")
+	case todo.SortCreatedAt:
+		return s.dialect.Time("created_at")
+	case todo.SortUpdatedAt:
+		return s.dialect.Time("updated_at")
+	}
+	return "id"
+}
+
+// escapeLike escapes the LIKE wildcards of s, to be used with ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// inClause returns "column IN (?, ...)" with one placeholder per id
+func inClause(column string, ids []int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ",")), args
+}
+
+// ScopeClause returns the condition limiting a statement to the tasks the
+// scope of ctx sees, or "" when it sees every task. prefix qualifies the
+// columns when the statement joins tables, e.g. "t.".
+func ScopeClause(ctx context.Context, prefix string) (string, []interface{}) {
+	scope := store.ScopeFromContext(ctx)
+	return scopeClause(scope, prefix, scope.ReadLists())
+}
+
+// scopeClause returns the condition matching the tasks of the scope owner
+// and the tasks of lists
+func scopeClause(scope store.Scope, prefix string, lists []int) (string, []interface{}) {
+	if scope.All {
+		return "", nil
+	}
+	clause, args := prefix+"owner_id = ?", []interface{}{scope.Owner}
+	if len(lists) > 0 {
+		listClause, ids := inClause(prefix+"list_id", lists)
+		clause, args = "("+clause+" OR "+listClause+")", append(args, ids...)
+	}
+	return clause, args
+}
+
+// scoped appends the condition matching the tasks the scope of ctx sees to
+// a WHERE clause and its args
+func scoped(ctx context.Context, where string, args []interface{}) (string, []interface{}) {
+	clause, scopeArgs := ScopeClause(ctx, "")
+	return appendClause(where, args, clause, scopeArgs)
+}
+
+// scopedWrite appends the condition matching the tasks the scope of ctx
+// changes to a WHERE clause and its args
+func scopedWrite(ctx context.Context, where string, args []interface{}) (string, []interface{}) {
+	scope := store.ScopeFromContext(ctx)
+	clause, scopeArgs := scopeClause(scope, "", scope.WriteLists())
+	return appendClause(where, args, clause, scopeArgs)
+}
+
+func appendClause(where string, args []interface{}, clause string, clauseArgs []interface{}) (string, []interface{}) {
+	if clause == "" {
+		return where, args
+	}
+	return where + " AND " + clause, append(args, clauseArgs...)
+}
+
+// ListTodoTasks returns one page of the tasks matching query, ordered by
+// query.Sort and then id. Pages are keyed on the last row of the previous
+// page rather than an offset, so concurrent inserts do not shift them.
+func (s *Store) ListTodoTasks(ctx context.Context, query todo.TodoQuery) (todo.TodoListResponse, error) {
+	where := []string{"deleted_at IS NULL"}
+	if query.Deleted {
+		where = []string{"deleted_at IS NOT NULL"}
+	}
+	var args []interface{}
+
+	if len(query.Ids) > 0 {
+		clause, ids := inClause("id", query.Ids)
+		where = append(where, clause)
+		args = append(args, ids...)
+	}
+	if clause, scopeArgs := ScopeClause(ctx, ""); clause != "" {
+		where = append(where, clause)
+		args = append(args, scopeArgs...)
+	}
+	if query.Owner != "" {
+		where = append(where, "owner_id = ?")
+		args = append(args, query.Owner)
+	}
+	if query.ListId != 0 {
+		where = append(where, "list_id = ?")
+		args = append(args, query.ListId)
+	}
+	if query.Completed != nil {
+		where = append(where, "completed = ?")
+		args = append(args, *query.Completed)
+	}
+	if query.Q != "" {
+		where = append(where, `name `+s.dialect.Like+` ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(query.Q)+"%")
+	}
+
+	field, desc := query.SortField()
+	column := s.sortColumn(field)
+	op, direction := ">", "ASC"
+	if desc {
+		op, direction = "<", "DESC"
+	}
+
+	if query.Cursor != "" {
+		cursor, err := query.DecodeCursor()
+		if err != nil {
+			return todo.TodoListResponse{}, err
+		}
+		switch field {
+		case todo.SortID:
+			where = append(where, "id "+op+" ?")
+			args = append(args, cursor.Id)
+		default:
+			var value interface{} = cursor.Value
+			placeholder := "?"
+			if field == todo.SortCreatedAt || field == todo.SortUpdatedAt {
+				value, _ = cursor.Time()
+				placeholder = s.dialect.Time("?")
+			}
+			where = append(where, fmt.Sprintf("(%[1]s %[2]s %[3]s OR (%[1]s = %[3]s AND id %[2]s ?))", column, op, placeholder))
+			args = append(args, value, value, cursor.Id)
+		}
+	}
+
+	queryDataSQL := "SELECT " + TodoColumns + " FROM todo WHERE " + strings.Join(where, " AND ")
+	orderBy := column + " " + direction
+	if field != todo.SortID {
+		orderBy += ", id " + direction
+	}
+	// Fetch one extra row to know whether another page follows
+	queryDataSQL += fmt.Sprintf(" ORDER BY %s LIMIT %d", orderBy, query.Limit+1)
+
+	todos, err := s.selectTodos(ctx, queryDataSQL, args...)
+	if err != nil {
+		return todo.TodoListResponse{}, err
+	}
+
+	response := todo.TodoListResponse{Items: todos}
+	if len(todos) > query.Limit {
+		response.Items = todos[:query.Limit]
+		response.NextCursor = query.NextCursor(response.Items[query.Limit-1])
+	}
+	return response, nil
+}
+
+func (s *Store) CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	insertDataSQL := `INSERT INTO todo (name, description, completed, due_at, priority, tags, created_at, updated_at, completed_at, owner_id, list_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+
+	now := time.Now().UTC()
+	var completedAt interface{}
+	if requestInput.Completed {
+		completedAt = now
+	}
+	var id int
+	queryCtx, span := s.StartQuery(ctx, "todo", insertDataSQL)
+	err := s.conn.QueryRowxContext(queryCtx, s.conn.Rebind(insertDataSQL), requestInput.Name, requestInput.Description, requestInput.Completed,
+		utcTime(requestInput.DueAt), requestInput.Priority, encodeTags(requestInput.Tags), now, now, completedAt,
+		store.ScopeFromContext(ctx).Owner, nullID(requestInput.ListId)).Scan(&id)
+	EndQuery(span, 1, err)
+	if err != nil {
+		return todo.TodoResponse{}, s.Error(ctx, err)
+	}
+
+	todos, err := s.GetTodoTaskByID(ctx, []int{id})
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	return todos[0], nil
+}
+
+func (s *Store) GetTodoTaskByID(ctx context.Context, ids []int) ([]todo.TodoResponse, error) {
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no task ids given", todo.ErrNotFound)
+	}
+
+	// Query tasks from the 'todo' table with dynamic-length IDs.
+	clause, args := inClause("id", ids)
+	clause, args = scoped(ctx, clause+" AND deleted_at IS NULL", args)
+	todos, err := s.selectTodos(ctx, "SELECT "+TodoColumns+" FROM todo WHERE "+clause+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(todos) == 0 {
+		return nil, fmt.Errorf("%w: no task found with ids %v", todo.ErrNotFound, ids)
+	}
+	return todos, nil
+}
+
+// DeleteTodoTaskByID moves the live tasks among ids to the trash in one
+// statement and reports the ids that matched none. When version is not 0
+// only tasks at that version are deleted.
+func (s *Store) DeleteTodoTaskByID(ctx context.Context, id []int, version int) (todo.TodoDeleteResponse, error) {
+	if len(id) == 0 {
+		return todo.TodoDeleteResponse{}, fmt.Errorf("%w: no task ids given", todo.ErrNotFound)
+	}
+	clause, args := inClause("id", id)
+	args = append([]interface{}{time.Now().UTC()}, args...)
+	clause, args = scopedWrite(ctx, clause+" AND deleted_at IS NULL AND (? = 0 OR version = ?)", append(args, version, version))
+	deleteDataSQL := "UPDATE todo SET deleted_at = ?, version = version + 1 WHERE " + clause + " RETURNING id"
+
+	var deleted []int
+	queryCtx, span := s.StartQuery(ctx, "todo", deleteDataSQL)
+	err := sqlx.SelectContext(queryCtx, s.conn, &deleted, s.conn.Rebind(deleteDataSQL), args...)
+	EndQuery(span, int64(len(deleted)), err)
+	if err != nil {
+		logging.FromContext(ctx).Error("deleting tasks failed", "ids", id, "error", err)
+		return todo.TodoDeleteResponse{}, s.Error(ctx, err)
+	}
+
+	if len(deleted) == 0 && len(id) == 1 {
+		return todo.TodoDeleteResponse{}, s.missedWrite(ctx, id[0], version)
+	}
+	if len(deleted) == 0 {
+		return todo.TodoDeleteResponse{}, fmt.Errorf("%w: no task found with ids %v", todo.ErrNotFound, id)
+	}
+	return todo.NewTodoDeleteResponse(id, deleted), nil
+}
+
+// RestoreTodoTaskByID moves the task with id out of the trash
+func (s *Store) RestoreTodoTaskByID(ctx context.Context, id int) (todo.TodoResponse, error) {
+	where, args := scopedWrite(ctx, "id = ? AND deleted_at IS NOT NULL", []interface{}{time.Now().UTC(), id})
+	rowsAffected, err := s.exec(ctx, "todo", "UPDATE todo SET deleted_at = NULL, updated_at = ?, version = version + 1 WHERE "+where, args...)
+	if err != nil {
+		return todo.TodoResponse{}, s.Error(ctx, err)
+	}
+	if rowsAffected == 0 {
+		// A task the scope sees but cannot change is in a list it only views
+		trash, err := s.ListTodoTasks(ctx, todo.TodoQuery{Ids: []int{id}, Deleted: true, Limit: 1})
+		if err != nil {
+			return todo.TodoResponse{}, err
+		}
+		if len(trash.Items) > 0 {
+			return todo.TodoResponse{}, fmt.Errorf("%w: task %d is in list %d, which you may only view", todo.ErrForbidden, id, trash.Items[0].ListId)
+		}
+		return todo.TodoResponse{}, fmt.Errorf("%w: no task with id %d in the trash", todo.ErrNotFound, id)
+	}
+
+	todos, err := s.GetTodoTaskByID(ctx, []int{id})
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	todos[0].Message = "Success"
+	return todos[0], nil
+}
+
+// PurgeTodoTasks permanently removes the tasks moved to the trash before
+// deletedBefore and returns how many were removed
+func (s *Store) PurgeTodoTasks(ctx context.Context, deletedBefore time.Time) (int, error) {
+	where, args := scopedWrite(ctx, "deleted_at IS NOT NULL AND "+s.dialect.Time("deleted_at")+" < "+s.dialect.Time("?"), []interface{}{deletedBefore.UTC()})
+	rowsAffected, err := s.exec(ctx, "todo", "DELETE FROM todo WHERE "+where, args...)
+	if err != nil {
+		return 0, s.Error(ctx, err)
+	}
+	return int(rowsAffected), nil
+}
+
+func (s *Store) UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error) {
+	// completed_at keeps its first value while the task stays completed
+	now := time.Now().UTC()
+	where, args := scopedWrite(ctx, "id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)", []interface{}{
+		requestInput.Name, requestInput.Description, requestInput.Completed, utcTime(requestInput.DueAt), requestInput.Priority,
+		encodeTags(requestInput.Tags), nullID(requestInput.ListId), now, requestInput.Completed, now, requestInput.Id,
+		requestInput.Version, requestInput.Version,
+	})
+	updateDataSQL := `UPDATE todo SET name = ?, description = ?, completed = ?, due_at = ?, priority = ?, tags = ?, list_id = ?, updated_at = ?,
+		completed_at = CASE WHEN ? THEN COALESCE(completed_at, ?) ELSE NULL END, version = version + 1
+		WHERE ` + where
+	rowsAffected, err := s.exec(ctx, "todo", updateDataSQL, args...)
+	if err != nil {
+		return todo.TodoResponse{}, s.Error(ctx, err)
+	}
+
+	if rowsAffected == 0 {
+		return todo.TodoResponse{}, s.missedWrite(ctx, requestInput.Id, requestInput.Version)
+	}
+
+	todos, err := s.GetTodoTaskByID(ctx, []int{requestInput.Id})
+	if err != nil {
+		return todo.TodoResponse{}, err
+	}
+	todos[0].Message = "Success"
+	return todos[0], nil
+}
+
+// missedWrite returns the error of a write to the task with id that
+// changed no row: ErrForbidden when the scope of ctx sees the task but
+// cannot change it, ErrPreconditionFailed when the task is at another
+// version than the one the write expected, ErrNotFound when there is no
+// such task
+func (s *Store) missedWrite(ctx context.Context, id, version int) error {
+	todos, err := s.GetTodoTaskByID(ctx, []int{id})
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		return fmt.Errorf("%w: no task found with id %d", todo.ErrNotFound, id)
+	case err != nil:
+		return err
+	case !store.ScopeFromContext(ctx).CanWrite(todos[0].OwnerId, todos[0].ListId):
+		return fmt.Errorf("%w: task %d is in list %d, which you may only view", todo.ErrForbidden, id, todos[0].ListId)
+	case version != 0 && todos[0].Version != version:
+		return fmt.Errorf("%w: task %d is at version %d, not %d", todo.ErrPreconditionFailed, id, todos[0].Version, version)
+	}
+	return fmt.Errorf("%w: no task found with id %d", todo.ErrNotFound, id)
+}
+
+// exec runs statement on table and returns the number of rows it changed
+func (s *Store) exec(ctx context.Context, table, statement string, args ...interface{}) (rowsAffected int64, err error) {
+	ctx, span := s.StartQuery(ctx, table, statement)
+	defer func() { EndQuery(span, rowsAffected, err) }()
+
+	result, err := s.conn.ExecContext(ctx, s.conn.Rebind(statement), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CountTodoTasks returns the number of completed and open tasks
+func (s *Store) CountTodoTasks(ctx context.Context) (todo.TodoCounts, error) {
+	var rows []struct {
+		Completed bool `db:"completed"`
+		Count     int  `db:"count"`
+	}
+	where, args := scoped(ctx, "deleted_at IS NULL", nil)
+	countSQL := "SELECT completed, COUNT(*) AS count FROM todo WHERE " + where + " GROUP BY completed"
+	queryCtx, span := s.StartQuery(ctx, "todo", countSQL)
+	err := sqlx.SelectContext(queryCtx, s.conn, &rows, s.conn.Rebind(countSQL), args...)
+	EndQuery(span, int64(len(rows)), err)
+	if err != nil {
+		return todo.TodoCounts{}, s.Error(ctx, err)
+	}
+
+	var counts todo.TodoCounts
+	for _, row := range rows {
+		if row.Completed {
+			counts.Completed += row.Count
+		} else {
+			counts.Open += row.Count
+		}
+	}
+	return counts, nil
+}
+
+// BatchTodoTasks applies the operations of request in order in one
+// transaction. In best effort mode each operation runs in a savepoint that
+// is rolled back when it fails, in atomic mode the first failed operation
+// rolls back the whole transaction.
+func (s *Store) BatchTodoTasks(ctx context.Context, request todo.TodoBatchRequest) (todo.TodoBatchResponse, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return todo.TodoBatchResponse{}, s.Error(ctx, err)
+	}
+	// Rolling back after a commit does nothing
+	defer tx.Rollback()
+	txStore := &Store{db: s.db, conn: tx, dialect: s.dialect}
+
+	response := todo.TodoBatchResponse{Mode: request.Mode, Results: make([]todo.TodoBatchResult, 0, len(request.Operations))}
+	for i, op := range request.Operations {
+		if request.Mode == todo.BatchBestEffort {
+			if _, err := txStore.exec(ctx, "", "SAVEPOINT batch_operation"); err != nil {
+				return todo.TodoBatchResponse{}, s.Error(ctx, err)
+			}
+		}
+
+		result := store.ApplyOperation(ctx, txStore, op)
+		response.Results = append(response.Results, result)
+		if result.Err != nil && !store.OperationFailed(result.Err) {
+			return todo.TodoBatchResponse{}, result.Err
+		}
+
+		switch {
+		case result.Err != nil && request.Mode == todo.BatchAtomic:
+			for _, rest := range request.Operations[i+1:] {
+				response.Results = append(response.Results, todo.TodoBatchResult{Op: rest.Op})
+			}
+			response.Abort(i)
+			return response, nil
+		case result.Err != nil:
+			_, err = txStore.exec(ctx, "", "ROLLBACK TO SAVEPOINT batch_operation")
+		case request.Mode == todo.BatchBestEffort:
+			_, err = txStore.exec(ctx, "", "RELEASE SAVEPOINT batch_operation")
+		}
+		if err != nil {
+			return todo.TodoBatchResponse{}, s.Error(ctx, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return todo.TodoBatchResponse{}, s.Error(ctx, err)
+	}
+	response.Committed = true
+	return response, nil
+}
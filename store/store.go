@@ -3,15 +3,41 @@ package store
 import (
 	"context"
 	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"time"
 )
 
 //go:generate mockgen -destination mockservice/mock_service.go -package mockservice github.com/RanbirSingh-Velotio/todo-service/pkg/store Service
 type StoreSvc interface {
-	GetTaskList() []todo.TodoResponse
+	ListTodoTasks(ctx context.Context, query todo.TodoQuery) (todo.TodoListResponse, error)
 	CreateTodoTask(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error)
-	GetTodoTaskByID(ctx context.Context, id []int) []todo.TodoResponse
-	DeleteTodoTaskByID(ctx context.Context, id []int) todo.TodoResponse
-	UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) todo.TodoResponse
+	GetTodoTaskByID(ctx context.Context, id []int) ([]todo.TodoResponse, error)
+	DeleteTodoTaskByID(ctx context.Context, id []int, version int) (todo.TodoDeleteResponse, error)
+	RestoreTodoTaskByID(ctx context.Context, id int) (todo.TodoResponse, error)
+	PurgeTodoTasks(ctx context.Context, deletedBefore time.Time) (int, error)
+	UpdateTodoTaskByID(ctx context.Context, requestInput todo.TodoRequestInput) (todo.TodoResponse, error)
+	SearchTodoTasks(ctx context.Context, query todo.TodoSearchQuery) (todo.TodoSearchResponse, error)
+	CountTodoTasks(ctx context.Context) (todo.TodoCounts, error)
+	BatchTodoTasks(ctx context.Context, request todo.TodoBatchRequest) (todo.TodoBatchResponse, error)
+	ListStore
+}
+
+// ListStore keeps the lists and their members. Its methods are not scoped,
+// the service checks the role of the caller in a list before calling them.
+type ListStore interface {
+	// CreateList creates a list with owner as its owner, or no member when owner is empty
+	CreateList(ctx context.Context, input todo.ListRequestInput, owner string) (todo.ListResponse, error)
+	GetList(ctx context.Context, id int) (todo.ListResponse, error)
+	// ListLists returns the lists member is a member of, or every list when member is empty
+	ListLists(ctx context.Context, member string) ([]todo.ListResponse, error)
+	UpdateList(ctx context.Context, input todo.ListRequestInput) (todo.ListResponse, error)
+	// DeleteList deletes a list and its members, its tasks stay with their owners
+	DeleteList(ctx context.Context, id int) error
+	// PutListMember adds member to a list or changes its role. Both fail
+	// with ErrConflict when they would leave the list without an owner.
+	PutListMember(ctx context.Context, id int, member todo.ListMember) error
+	DeleteListMember(ctx context.Context, id int, subject string) error
+	// ListRoles returns the role subject holds in every list it is a member of, by list id
+	ListRoles(ctx context.Context, subject string) (map[int]string, error)
 }
 
 var defaultService StoreSvc
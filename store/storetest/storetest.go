@@ -0,0 +1,676 @@
+// Package storetest holds the tests every store.StoreSvc backend has to pass
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/auth"
+	"github.com/RanbirSingh-Velotio/todo-service/pkg/todo"
+	"github.com/RanbirSingh-Velotio/todo-service/store"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run runs the backend tests against stores returned by newStore, which
+// must return an empty store on every call
+func Run(t *testing.T, newStore func(t *testing.T) store.StoreSvc) {
+	t.Run("CRUD", func(t *testing.T) { testCRUD(t, newStore(t)) })
+	t.Run("ListTodoTasks", func(t *testing.T) { testListTodoTasks(t, newStore(t)) })
+	t.Run("CountTodoTasks", func(t *testing.T) { testCountTodoTasks(t, newStore(t)) })
+	t.Run("Trash", func(t *testing.T) { testTrash(t, newStore(t)) })
+	t.Run("BatchTodoTasks", func(t *testing.T) { testBatchTodoTasks(t, newStore(t)) })
+	t.Run("Versions", func(t *testing.T) { testVersions(t, newStore(t)) })
+	t.Run("Ownership", func(t *testing.T) { testOwnership(t, newStore(t)) })
+	t.Run("Lists", func(t *testing.T) { testLists(t, newStore(t)) })
+	t.Run("ListOwners", func(t *testing.T) { testListOwners(t, newStore(t)) })
+	t.Run("CancelledContext", func(t *testing.T) { testCancelledContext(t, newStore(t)) })
+}
+
+func testCRUD(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	created, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{
+		Name: "buy milk", Description: "semi skimmed", DueAt: &due, Priority: todo.PriorityHigh, Tags: []string{"errands"},
+	})
+	if err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	if created.Id == 0 || created.CreatedAt == nil || created.UpdatedAt == nil || created.CompletedAt != nil {
+		t.Errorf("CreateTodoTask() = %+v, want an id and timestamps\n", created)
+	}
+	if created.DueAt == nil || !created.DueAt.Equal(due) || created.DueAt.Location() != time.UTC {
+		t.Errorf("CreateTodoTask() due_at = %v, want %v in UTC\n", created.DueAt, due)
+	}
+	if fmt.Sprint(created.Tags) != "[errands]" || created.Priority != todo.PriorityHigh {
+		t.Errorf("CreateTodoTask() = %+v, want the input fields\n", created)
+	}
+
+	updated, err := s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: created.Id, Name: "buy oat milk", Completed: true, Priority: todo.PriorityLow})
+	if err != nil {
+		t.Fatalf("UpdateTodoTaskByID() err = %v\n", err)
+	}
+	if updated.Name != "buy oat milk" || !updated.Completed || updated.CompletedAt == nil || updated.DueAt != nil || len(updated.Tags) != 0 {
+		t.Errorf("UpdateTodoTaskByID() = %+v, want the new fields\n", updated)
+	}
+	completedAt := *updated.CompletedAt
+
+	// completed_at keeps its first value while the task stays completed
+	updated, err = s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: created.Id, Name: "buy milk", Completed: true, Priority: todo.PriorityLow})
+	if err != nil {
+		t.Fatalf("UpdateTodoTaskByID() err = %v\n", err)
+	}
+	if updated.CompletedAt == nil || !updated.CompletedAt.Equal(completedAt) {
+		t.Errorf("UpdateTodoTaskByID() completed_at = %v, want %v\n", updated.CompletedAt, completedAt)
+	}
+
+	got, err := s.GetTodoTaskByID(ctx, []int{created.Id, created.Id + 100})
+	if err != nil {
+		t.Fatalf("GetTodoTaskByID() err = %v\n", err)
+	}
+	if len(got) != 1 || got[0].Id != created.Id || got[0].Name != "buy milk" {
+		t.Errorf("GetTodoTaskByID() = %+v, want the updated task\n", got)
+	}
+
+	if _, err := s.DeleteTodoTaskByID(ctx, []int{created.Id}, 0); err != nil {
+		t.Fatalf("DeleteTodoTaskByID() err = %v\n", err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"GetTodoTaskByID", func() error {
+			_, err := s.GetTodoTaskByID(ctx, []int{created.Id})
+			return err
+		}},
+		{"UpdateTodoTaskByID", func() error {
+			_, err := s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: created.Id, Name: "a", Priority: todo.PriorityLow})
+			return err
+		}},
+		{"DeleteTodoTaskByID", func() error {
+			_, err := s.DeleteTodoTaskByID(ctx, []int{created.Id}, 0)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, todo.ErrNotFound) {
+			t.Errorf("%s() err = %v, want %v\n", tt.name, err, todo.ErrNotFound)
+		}
+	}
+}
+
+func testListTodoTasks(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	names := []string{"buy milk", "walk dog", "Buy bread", "call mom", "pay 100% rent", "wash car", "buy_eggs"}
+	for i, name := range names {
+		_, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: name, Completed: i%2 == 0, Priority: todo.PriorityMedium})
+		if err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+	completed := true
+
+	tests := []struct {
+		name  string
+		query todo.TodoQuery
+		want  []int
+	}{
+		{"all", todo.TodoQuery{}, []int{1, 2, 3, 4, 5, 6, 7}},
+		{"ids", todo.TodoQuery{Ids: []int{2, 4, 9}}, []int{2, 4}},
+		{"completed", todo.TodoQuery{Completed: &completed}, []int{1, 3, 5, 7}},
+		{"name substring", todo.TodoQuery{Q: "buy"}, []int{1, 3, 7}},
+		{"like wildcards are literal", todo.TodoQuery{Q: "100%"}, []int{5}},
+		{"underscore is literal", todo.TodoQuery{Q: "y_e"}, []int{7}},
+		{"descending id", todo.TodoQuery{Sort: "-id"}, []int{7, 6, 5, 4, 3, 2, 1}},
+		{"name", todo.TodoQuery{Sort: "name"}, []int{3, 1, 7, 4, 5, 2, 6}},
+		{"descending created_at", todo.TodoQuery{Sort: "-created_at"}, []int{7, 6, 5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		// Walk every page and collect the ids
+		for _, limit := range []int{2, todo.DefaultLimit} {
+			t.Run(fmt.Sprintf("%s/limit %d", tt.name, limit), func(t *testing.T) {
+				query := tt.query
+				query.Limit = limit
+				var got []int
+				for page := 0; page < 10; page++ {
+					response, err := s.ListTodoTasks(ctx, query)
+					if err != nil {
+						t.Fatalf("ListTodoTasks() err = %v\n", err)
+					}
+					for _, item := range response.Items {
+						got = append(got, item.Id)
+					}
+					if response.NextCursor == "" {
+						break
+					}
+					query.Cursor = response.NextCursor
+				}
+				if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+					t.Errorf("ListTodoTasks() ids = %v, want %v\n", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func testCountTodoTasks(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	counts, err := s.CountTodoTasks(ctx)
+	if err != nil {
+		t.Fatalf("CountTodoTasks() err = %v\n", err)
+	}
+	if counts != (todo.TodoCounts{}) {
+		t.Errorf("CountTodoTasks() = %+v, want no tasks\n", counts)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Completed: i < 2, Priority: todo.PriorityMedium}); err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+	counts, err = s.CountTodoTasks(ctx)
+	if err != nil {
+		t.Fatalf("CountTodoTasks() err = %v\n", err)
+	}
+	if want := (todo.TodoCounts{Completed: 2, Open: 3}); counts != want {
+		t.Errorf("CountTodoTasks() = %+v, want %+v\n", counts, want)
+	}
+}
+
+func testTrash(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Priority: todo.PriorityMedium}); err != nil {
+			t.Fatalf("CreateTodoTask() err = %v\n", err)
+		}
+	}
+
+	deleted, err := s.DeleteTodoTaskByID(ctx, []int{3, 1, 9}, 0)
+	if err != nil {
+		t.Fatalf("DeleteTodoTaskByID() err = %v\n", err)
+	}
+	if fmt.Sprint(deleted.Deleted) != "[1 3]" || fmt.Sprint(deleted.NotFound) != "[9]" {
+		t.Errorf("DeleteTodoTaskByID() = %+v, want deleted [1 3] and not found [9]\n", deleted)
+	}
+
+	listIds := func(deleted bool) string {
+		response, err := s.ListTodoTasks(ctx, todo.TodoQuery{Deleted: deleted, Limit: todo.DefaultLimit})
+		if err != nil {
+			t.Fatalf("ListTodoTasks() err = %v\n", err)
+		}
+		var ids []int
+		for _, item := range response.Items {
+			if (item.DeletedAt != nil) != deleted {
+				t.Errorf("ListTodoTasks() item %d deleted_at = %v\n", item.Id, item.DeletedAt)
+			}
+			ids = append(ids, item.Id)
+		}
+		return fmt.Sprint(ids)
+	}
+	if got := listIds(false); got != "[2]" {
+		t.Errorf("ListTodoTasks() ids = %v, want [2]\n", got)
+	}
+	if got := listIds(true); got != "[1 3]" {
+		t.Errorf("ListTodoTasks() trash ids = %v, want [1 3]\n", got)
+	}
+	if counts, err := s.CountTodoTasks(ctx); err != nil || counts.Open != 1 {
+		t.Errorf("CountTodoTasks() = %+v, %v, want 1 open task\n", counts, err)
+	}
+
+	restored, err := s.RestoreTodoTaskByID(ctx, 1)
+	if err != nil {
+		t.Fatalf("RestoreTodoTaskByID() err = %v\n", err)
+	}
+	if restored.Id != 1 || restored.DeletedAt != nil {
+		t.Errorf("RestoreTodoTaskByID() = %+v, want task 1 out of the trash\n", restored)
+	}
+	for _, id := range []int{1, 2, 9} {
+		if _, err := s.RestoreTodoTaskByID(ctx, id); !errors.Is(err, todo.ErrNotFound) {
+			t.Errorf("RestoreTodoTaskByID(%d) err = %v, want %v\n", id, err, todo.ErrNotFound)
+		}
+	}
+
+	purged, err := s.PurgeTodoTasks(ctx, time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Errorf("PurgeTodoTasks() = %v, %v, want nothing purged before the deletion\n", purged, err)
+	}
+	purged, err = s.PurgeTodoTasks(ctx, time.Now().Add(time.Hour))
+	if err != nil || purged != 1 {
+		t.Errorf("PurgeTodoTasks() = %v, %v, want 1 purged\n", purged, err)
+	}
+	if got := listIds(true); got != "[]" {
+		t.Errorf("ListTodoTasks() trash ids = %v, want []\n", got)
+	}
+	if got := listIds(false); got != "[1 2]" {
+		t.Errorf("ListTodoTasks() ids = %v, want [1 2]\n", got)
+	}
+}
+
+func testBatchTodoTasks(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	if _, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "existing", Priority: todo.PriorityMedium}); err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	operations := []todo.TodoBatchOperation{
+		{Op: todo.OpCreate, Todo: &todo.TodoRequestInput{Name: "new", Priority: todo.PriorityLow}},
+		{Op: todo.OpUpdate, Todo: &todo.TodoRequestInput{Id: 1, Name: "renamed", Priority: todo.PriorityHigh}},
+		{Op: todo.OpUpdate, Todo: &todo.TodoRequestInput{Id: 99, Name: "missing", Priority: todo.PriorityHigh}},
+		{Op: todo.OpDelete, Ids: []int{1}},
+	}
+	names := func() string {
+		response, err := s.ListTodoTasks(ctx, todo.TodoQuery{Limit: todo.DefaultLimit})
+		if err != nil {
+			t.Fatalf("ListTodoTasks() err = %v\n", err)
+		}
+		var names []string
+		for _, item := range response.Items {
+			names = append(names, item.Name)
+		}
+		return fmt.Sprint(names)
+	}
+
+	// The update of task 99 fails, so the atomic batch changes nothing
+	response, err := s.BatchTodoTasks(ctx, todo.TodoBatchRequest{Mode: todo.BatchAtomic, Operations: operations})
+	if err != nil {
+		t.Fatalf("BatchTodoTasks() err = %v\n", err)
+	}
+	if response.Committed || len(response.Results) != len(operations) {
+		t.Fatalf("BatchTodoTasks() = %+v, want %d results of a rolled back batch\n", response, len(operations))
+	}
+	for i, result := range response.Results {
+		if i == 2 && !errors.Is(result.Err, todo.ErrNotFound) {
+			t.Errorf("BatchTodoTasks() results[%d] err = %v, want %v\n", i, result.Err, todo.ErrNotFound)
+		}
+		if i != 2 && !errors.Is(result.Err, todo.ErrBatchAborted) {
+			t.Errorf("BatchTodoTasks() results[%d] err = %v, want %v\n", i, result.Err, todo.ErrBatchAborted)
+		}
+	}
+	if got := names(); got != "[existing]" {
+		t.Errorf("ListTodoTasks() names = %v after a rolled back batch, want [existing]\n", got)
+	}
+
+	// In best effort mode every operation but the failed one is applied
+	response, err = s.BatchTodoTasks(ctx, todo.TodoBatchRequest{Mode: todo.BatchBestEffort, Operations: operations})
+	if err != nil {
+		t.Fatalf("BatchTodoTasks() err = %v\n", err)
+	}
+	if !response.Committed || response.Failed() != 1 || !errors.Is(response.Results[2].Err, todo.ErrNotFound) {
+		t.Errorf("BatchTodoTasks() = %+v, want a committed batch with results[2] not found\n", response)
+	}
+	if created := response.Results[0].Todo; created == nil || created.Name != "new" {
+		t.Errorf("BatchTodoTasks() results[0].todo = %+v, want the created task\n", created)
+	}
+	if deleted := response.Results[3].Deleted; deleted == nil || fmt.Sprint(deleted.Deleted) != "[1]" {
+		t.Errorf("BatchTodoTasks() results[3].deleted = %+v, want [1]\n", deleted)
+	}
+	if got := names(); got != "[new]" {
+		t.Errorf("ListTodoTasks() names = %v, want [new]\n", got)
+	}
+	trash, err := s.ListTodoTasks(ctx, todo.TodoQuery{Deleted: true, Limit: todo.DefaultLimit})
+	if err != nil || len(trash.Items) != 1 || trash.Items[0].Name != "renamed" {
+		t.Errorf("ListTodoTasks() trash = %+v, %v, want the renamed task\n", trash.Items, err)
+	}
+}
+
+func testVersions(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	created, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Priority: todo.PriorityMedium})
+	if err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("CreateTodoTask() version = %v, want 1\n", created.Version)
+	}
+
+	update := todo.TodoRequestInput{Id: created.Id, Name: "b", Priority: todo.PriorityMedium}
+	updated, err := s.UpdateTodoTaskByID(ctx, update)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("UpdateTodoTaskByID() = %+v, %v, want version 2\n", updated, err)
+	}
+	update.Version = 2
+	if updated, err = s.UpdateTodoTaskByID(ctx, update); err != nil || updated.Version != 3 {
+		t.Fatalf("UpdateTodoTaskByID() = %+v, %v, want version 3\n", updated, err)
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"stale update", func() error {
+			_, err := s.UpdateTodoTaskByID(ctx, update)
+			return err
+		}, todo.ErrPreconditionFailed},
+		{"stale delete", func() error {
+			_, err := s.DeleteTodoTaskByID(ctx, []int{created.Id}, 2)
+			return err
+		}, todo.ErrPreconditionFailed},
+		{"missing task", func() error {
+			_, err := s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: created.Id + 1, Name: "c", Priority: todo.PriorityMedium, Version: 1})
+			return err
+		}, todo.ErrNotFound},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.want) {
+			t.Errorf("%s err = %v, want %v\n", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := s.DeleteTodoTaskByID(ctx, []int{created.Id}, 3); err != nil {
+		t.Fatalf("DeleteTodoTaskByID() err = %v\n", err)
+	}
+	restored, err := s.RestoreTodoTaskByID(ctx, created.Id)
+	if err != nil || restored.Version != 5 {
+		t.Errorf("RestoreTodoTaskByID() = %+v, %v, want version 5\n", restored, err)
+	}
+}
+
+func testOwnership(t *testing.T, s store.StoreSvc) {
+	alice := auth.NewContext(context.Background(), auth.Principal{Subject: "alice"})
+	bob := auth.NewContext(context.Background(), auth.Principal{Subject: "bob"})
+	admin := auth.NewContext(context.Background(), auth.Principal{Subject: "root", Roles: []string{auth.RoleAdmin}})
+	input := todo.TodoRequestInput{Name: "buy milk", Priority: todo.PriorityMedium}
+
+	created, err := s.CreateTodoTask(alice, input)
+	if err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	if created.OwnerId != "alice" {
+		t.Errorf("CreateTodoTask() owner_id = %q, want alice\n", created.OwnerId)
+	}
+	if _, err := s.CreateTodoTask(bob, input); err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+
+	listIds := func(ctx context.Context, query todo.TodoQuery) string {
+		query.Limit = todo.DefaultLimit
+		response, err := s.ListTodoTasks(ctx, query)
+		if err != nil {
+			t.Fatalf("ListTodoTasks() err = %v\n", err)
+		}
+		var ids []int
+		for _, item := range response.Items {
+			ids = append(ids, item.Id)
+		}
+		return fmt.Sprint(ids)
+	}
+	lists := []struct {
+		name  string
+		ctx   context.Context
+		query todo.TodoQuery
+		want  string
+	}{
+		{"own tasks", alice, todo.TodoQuery{}, "[1]"},
+		{"other owner filter", alice, todo.TodoQuery{Owner: "bob"}, "[]"},
+		{"admin", admin, todo.TodoQuery{}, "[1 2]"},
+		{"admin owner filter", admin, todo.TodoQuery{Owner: "bob"}, "[2]"},
+		{"no principal", context.Background(), todo.TodoQuery{}, "[1 2]"},
+	}
+	for _, tt := range lists {
+		if got := listIds(tt.ctx, tt.query); got != tt.want {
+			t.Errorf("ListTodoTasks() %s = %v, want %v\n", tt.name, got, tt.want)
+		}
+	}
+
+	// Tasks of other owners do not exist as far as bob can tell
+	if _, err := s.GetTodoTaskByID(bob, []int{1}); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("GetTodoTaskByID() err = %v, want ErrNotFound\n", err)
+	}
+	update := input
+	update.Id, update.Name = 1, "changed"
+	if _, err := s.UpdateTodoTaskByID(bob, update); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("UpdateTodoTaskByID() err = %v, want ErrNotFound\n", err)
+	}
+	update.Version = 1
+	if _, err := s.UpdateTodoTaskByID(bob, update); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("UpdateTodoTaskByID() with version err = %v, want ErrNotFound\n", err)
+	}
+	if _, err := s.DeleteTodoTaskByID(bob, []int{1}, 0); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("DeleteTodoTaskByID() err = %v, want ErrNotFound\n", err)
+	}
+	if counts, err := s.CountTodoTasks(bob); err != nil || counts.Open != 1 {
+		t.Errorf("CountTodoTasks() = %+v, %v, want 1 open\n", counts, err)
+	}
+
+	// Admins change the tasks of every owner, which keep their owner
+	updated, err := s.UpdateTodoTaskByID(admin, update)
+	if err != nil || updated.OwnerId != "alice" {
+		t.Errorf("UpdateTodoTaskByID() = %+v, %v, want the task of alice\n", updated, err)
+	}
+	if _, err := s.DeleteTodoTaskByID(alice, []int{1}, 0); err != nil {
+		t.Fatalf("DeleteTodoTaskByID() err = %v\n", err)
+	}
+	if _, err := s.RestoreTodoTaskByID(bob, 1); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("RestoreTodoTaskByID() err = %v, want ErrNotFound\n", err)
+	}
+	if got := listIds(bob, todo.TodoQuery{Deleted: true}); got != "[]" {
+		t.Errorf("ListTodoTasks() trash of bob = %v, want []\n", got)
+	}
+	if _, err := s.RestoreTodoTaskByID(alice, 1); err != nil {
+		t.Errorf("RestoreTodoTaskByID() err = %v\n", err)
+	}
+}
+
+func testLists(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	created, err := s.CreateList(ctx, todo.ListRequestInput{Name: "home"}, "alice")
+	if err != nil {
+		t.Fatalf("CreateList() err = %v\n", err)
+	}
+	if created.Id == 0 || created.Name != "home" || fmt.Sprint(created.Members) != "[{alice owner}]" {
+		t.Errorf("CreateList() = %+v, want home owned by alice\n", created)
+	}
+	unowned, err := s.CreateList(ctx, todo.ListRequestInput{Name: "shared"}, "")
+	if err != nil || len(unowned.Members) != 0 || unowned.Members == nil {
+		t.Errorf("CreateList() without owner = %+v, %v, want no members\n", unowned, err)
+	}
+
+	for _, member := range []todo.ListMember{{Subject: "carol", Role: todo.ListRoleViewer}, {Subject: "bob", Role: todo.ListRoleViewer}, {Subject: "bob", Role: todo.ListRoleEditor}} {
+		if err := s.PutListMember(ctx, created.Id, member); err != nil {
+			t.Fatalf("PutListMember() err = %v\n", err)
+		}
+	}
+	if err := s.PutListMember(ctx, 99, todo.ListMember{Subject: "bob", Role: todo.ListRoleViewer}); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("PutListMember() unknown list err = %v, want ErrNotFound\n", err)
+	}
+	got, err := s.GetList(ctx, created.Id)
+	if err != nil || fmt.Sprint(got.Members) != "[{alice owner} {bob editor} {carol viewer}]" {
+		t.Errorf("GetList() = %+v, %v, want alice, bob and carol ordered by subject\n", got, err)
+	}
+
+	// A list always keeps an owner, failed changes leave it untouched
+	if err := s.PutListMember(ctx, created.Id, todo.ListMember{Subject: "alice", Role: todo.ListRoleEditor}); !errors.Is(err, todo.ErrConflict) {
+		t.Errorf("PutListMember() last owner err = %v, want ErrConflict\n", err)
+	}
+	if err := s.DeleteListMember(ctx, created.Id, "alice"); !errors.Is(err, todo.ErrConflict) {
+		t.Errorf("DeleteListMember() last owner err = %v, want ErrConflict\n", err)
+	}
+	if after, err := s.GetList(ctx, created.Id); err != nil || fmt.Sprint(after.Members) != fmt.Sprint(got.Members) || !after.UpdatedAt.Equal(*got.UpdatedAt) {
+		t.Errorf("GetList() after conflicts = %+v, %v, want %+v\n", after, err, got)
+	}
+
+	if lists, err := s.ListLists(ctx, "carol"); err != nil || len(lists) != 1 || lists[0].Id != created.Id {
+		t.Errorf("ListLists() carol = %+v, %v, want home\n", lists, err)
+	}
+	if lists, err := s.ListLists(ctx, ""); err != nil || len(lists) != 2 {
+		t.Errorf("ListLists() every list = %+v, %v, want 2 lists\n", lists, err)
+	}
+	updated, err := s.UpdateList(ctx, todo.ListRequestInput{Id: created.Id, Name: "house", Description: "chores"})
+	if err != nil || updated.Name != "house" || updated.Description != "chores" || len(updated.Members) != 3 {
+		t.Errorf("UpdateList() = %+v, %v, want house with its members\n", updated, err)
+	}
+	if _, err := s.UpdateList(ctx, todo.ListRequestInput{Id: 99, Name: "a"}); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("UpdateList() unknown list err = %v, want ErrNotFound\n", err)
+	}
+
+	// Members see the tasks of the list, only editors change them
+	scope := func(subject string) context.Context {
+		roles, err := s.ListRoles(ctx, subject)
+		if err != nil {
+			t.Fatalf("ListRoles() err = %v\n", err)
+		}
+		return store.NewContext(ctx, store.Scope{Owner: subject, Lists: roles})
+	}
+	alice, bob, carol, dave := scope("alice"), scope("bob"), scope("carol"), scope("dave")
+	task, err := s.CreateTodoTask(alice, todo.TodoRequestInput{Name: "buy milk", Priority: todo.PriorityMedium, ListId: created.Id})
+	if err != nil || task.ListId != created.Id {
+		t.Fatalf("CreateTodoTask() = %+v, %v, want a task of list %d\n", task, err, created.Id)
+	}
+	if _, err := s.CreateTodoTask(alice, todo.TodoRequestInput{Name: "private", Priority: todo.PriorityMedium}); err != nil {
+		t.Fatalf("CreateTodoTask() err = %v\n", err)
+	}
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		want int
+	}{{"owner", alice, 2}, {"editor", bob, 1}, {"viewer", carol, 1}, {"non member", dave, 0}} {
+		response, err := s.ListTodoTasks(tt.ctx, todo.TodoQuery{Limit: todo.DefaultLimit})
+		if err != nil || len(response.Items) != tt.want {
+			t.Errorf("ListTodoTasks() %s = %+v, %v, want %d tasks\n", tt.name, response.Items, err, tt.want)
+		}
+	}
+	if response, err := s.ListTodoTasks(alice, todo.TodoQuery{ListId: created.Id, Limit: todo.DefaultLimit}); err != nil || len(response.Items) != 1 {
+		t.Errorf("ListTodoTasks() of list = %+v, %v, want 1 task\n", response.Items, err)
+	}
+
+	update := task.RequestInput()
+	update.Name = "buy oat milk"
+	if updated, err := s.UpdateTodoTaskByID(bob, update); err != nil || updated.OwnerId != "alice" {
+		t.Errorf("UpdateTodoTaskByID() editor = %+v, %v, want the task of alice\n", updated, err)
+	}
+	update.Version = 0
+	if _, err := s.UpdateTodoTaskByID(carol, update); !errors.Is(err, todo.ErrForbidden) {
+		t.Errorf("UpdateTodoTaskByID() viewer err = %v, want ErrForbidden\n", err)
+	}
+	if _, err := s.UpdateTodoTaskByID(dave, update); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("UpdateTodoTaskByID() non member err = %v, want ErrNotFound\n", err)
+	}
+	if _, err := s.DeleteTodoTaskByID(carol, []int{task.Id}, 0); !errors.Is(err, todo.ErrForbidden) {
+		t.Errorf("DeleteTodoTaskByID() viewer err = %v, want ErrForbidden\n", err)
+	}
+	if _, err := s.DeleteTodoTaskByID(bob, []int{task.Id}, 0); err != nil {
+		t.Fatalf("DeleteTodoTaskByID() editor err = %v\n", err)
+	}
+	if _, err := s.RestoreTodoTaskByID(carol, task.Id); !errors.Is(err, todo.ErrForbidden) {
+		t.Errorf("RestoreTodoTaskByID() viewer err = %v, want ErrForbidden\n", err)
+	}
+	if _, err := s.RestoreTodoTaskByID(bob, task.Id); err != nil {
+		t.Errorf("RestoreTodoTaskByID() editor err = %v\n", err)
+	}
+
+	if err := s.DeleteListMember(ctx, created.Id, "carol"); err != nil {
+		t.Errorf("DeleteListMember() err = %v\n", err)
+	}
+	if err := s.DeleteListMember(ctx, created.Id, "carol"); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("DeleteListMember() again err = %v, want ErrNotFound\n", err)
+	}
+	if roles, err := s.ListRoles(ctx, "carol"); err != nil || len(roles) != 0 {
+		t.Errorf("ListRoles() = %v, %v, want none\n", roles, err)
+	}
+
+	// Tasks of a deleted list stay with their owners
+	if err := s.DeleteList(ctx, created.Id); err != nil {
+		t.Fatalf("DeleteList() err = %v\n", err)
+	}
+	if err := s.DeleteList(ctx, created.Id); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("DeleteList() again err = %v, want ErrNotFound\n", err)
+	}
+	if _, err := s.GetList(ctx, created.Id); !errors.Is(err, todo.ErrNotFound) {
+		t.Errorf("GetList() deleted err = %v, want ErrNotFound\n", err)
+	}
+	if roles, err := s.ListRoles(ctx, "bob"); err != nil || len(roles) != 0 {
+		t.Errorf("ListRoles() = %v, %v, want none\n", roles, err)
+	}
+	detached, err := s.GetTodoTaskByID(alice, []int{task.Id})
+	if err != nil || detached[0].ListId != 0 || detached[0].Version != 5 {
+		t.Errorf("GetTodoTaskByID() = %+v, %v, want version 5 in no list\n", detached, err)
+	}
+}
+
+// testListOwners has two owners demote each other at the same time, which
+// must leave the list with one of them as its owner
+func testListOwners(t *testing.T, s store.StoreSvc) {
+	ctx := context.Background()
+	list, err := s.CreateList(ctx, todo.ListRequestInput{Name: "home"}, "alice")
+	if err != nil {
+		t.Fatalf("CreateList() err = %v\n", err)
+	}
+	for i := 0; i < 20; i++ {
+		for _, subject := range []string{"alice", "bob"} {
+			if err := s.PutListMember(ctx, list.Id, todo.ListMember{Subject: subject, Role: todo.ListRoleOwner}); err != nil {
+				t.Fatalf("PutListMember() err = %v\n", err)
+			}
+		}
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, subject := range []string{"alice", "bob"} {
+			wg.Add(1)
+			go func(j int, subject string) {
+				defer wg.Done()
+				errs[j] = s.PutListMember(ctx, list.Id, todo.ListMember{Subject: subject, Role: todo.ListRoleViewer})
+			}(j, subject)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil && !errors.Is(err, todo.ErrConflict) && !errors.Is(err, todo.ErrUnavailable) {
+				t.Errorf("PutListMember() err = %v, want ErrConflict or ErrUnavailable\n", err)
+			}
+		}
+		got, err := s.GetList(ctx, list.Id)
+		if err != nil || got.Owners() != 1 {
+			t.Fatalf("GetList() = %+v, %v, want one owner left\n", got, err)
+		}
+	}
+}
+
+func testCancelledContext(t *testing.T, s store.StoreSvc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"list", func() error {
+			_, err := s.ListTodoTasks(ctx, todo.TodoQuery{Limit: todo.DefaultLimit})
+			return err
+		}},
+		{"create", func() error {
+			_, err := s.CreateTodoTask(ctx, todo.TodoRequestInput{Name: "a", Priority: todo.PriorityMedium})
+			return err
+		}},
+		{"update", func() error {
+			_, err := s.UpdateTodoTaskByID(ctx, todo.TodoRequestInput{Id: 1, Name: "a", Priority: todo.PriorityMedium})
+			return err
+		}},
+		{"delete", func() error {
+			_, err := s.DeleteTodoTaskByID(ctx, []int{1}, 0)
+			return err
+		}},
+		{"restore", func() error {
+			_, err := s.RestoreTodoTaskByID(ctx, 1)
+			return err
+		}},
+		{"purge", func() error {
+			_, err := s.PurgeTodoTasks(ctx, time.Now())
+			return err
+		}},
+		{"batch", func() error {
+			_, err := s.BatchTodoTasks(ctx, todo.TodoBatchRequest{Mode: todo.BatchAtomic, Operations: []todo.TodoBatchOperation{{Op: todo.OpDelete, Ids: []int{1}}}})
+			return err
+		}},
+		{"count", func() error {
+			_, err := s.CountTodoTasks(ctx)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("%s() err = %v, want %v\n", tt.name, err, context.Canceled)
+			}
+		})
+	}
+}
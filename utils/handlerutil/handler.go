@@ -1,7 +1,11 @@
 package handlerutil
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 )
 
 // Handler provides mechanism to start any handler type
@@ -12,24 +16,220 @@ type Handler interface {
 
 	// Start initiates the handler so it listens for incoming requests
 	Start() error
+
+	// Stop releases the handler once no new requests arrive, giving up
+	// on work still running when ctx is done
+	Stop(ctx context.Context) error
+
+	// Health returns why the handler cannot serve requests, or nil when it can
+	Health() error
 }
 
-// Handlers keeps all added handlers
-var handlers []Handler
+// State is where a handler is in its lifecycle
+type State string
+
+const (
+	StateAdded    State = "added"
+	StateStarting State = "starting"
+	StateRunning  State = "running"
+	StateFailed   State = "failed"
+	StateStopping State = "stopping"
+	StateStopped  State = "stopped"
+)
 
-// Add adds new handler to handlers
-func Add(handler Handler) {
-	handlers = append(handlers, handler)
+// Status reports the lifecycle state and health of a handler
+type Status struct {
+	Identity  string    `json:"identity"`
+	State     State     `json:"state"`
+	Healthy   bool      `json:"healthy"`
+	DependsOn []string  `json:"depends_on,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Since     time.Time `json:"since"`
 }
 
-// Start starts all added handlers
-// It will panic if any handler fails to start
-func Start() {
-	var err error
-	for _, h := range handlers {
-		err = h.Start()
+type entry struct {
+	handler   Handler
+	dependsOn []string
+	state     State
+	err       error
+	since     time.Time
+}
+
+// Registry starts handlers after the handlers they depend on and stops
+// them in the reverse order
+type Registry struct {
+	mu      sync.Mutex
+	entries []*entry
+	// started lists the entries in the order they were started
+	started []*entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Add adds handler, to be started after the handlers named in dependsOn
+func (r *Registry) Add(handler Handler, dependsOn ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, &entry{handler: handler, dependsOn: dependsOn, state: StateAdded, since: time.Now()})
+}
+
+func (r *Registry) setState(e *entry, state State, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.state, e.err, e.since = state, err, time.Now()
+}
+
+// order returns the entries with every handler after its dependencies,
+// otherwise keeping the order they were added in
+func (r *Registry) order() ([]*entry, error) {
+	byIdentity := map[string]*entry{}
+	for _, e := range r.entries {
+		identity := e.handler.GetIdentity()
+		if _, ok := byIdentity[identity]; ok {
+			return nil, fmt.Errorf("handler %s is added twice", identity)
+		}
+		byIdentity[identity] = e
+	}
+
+	var ordered []*entry
+	visited := map[*entry]bool{}
+	visiting := map[*entry]bool{}
+	var visit func(e *entry) error
+	visit = func(e *entry) error {
+		if visited[e] {
+			return nil
+		}
+		if visiting[e] {
+			return fmt.Errorf("handler %s depends on itself", e.handler.GetIdentity())
+		}
+		visiting[e] = true
+		for _, dependency := range e.dependsOn {
+			d, ok := byIdentity[dependency]
+			if !ok {
+				return fmt.Errorf("handler %s depends on unknown handler %s", e.handler.GetIdentity(), dependency)
+			}
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+		visiting[e] = false
+		visited[e] = true
+		ordered = append(ordered, e)
+		return nil
+	}
+	for _, e := range r.entries {
+		if err := visit(e); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Start starts the added handlers after their dependencies. When one fails
+// the handlers already started are stopped again and its error is returned.
+func (r *Registry) Start() error {
+	r.mu.Lock()
+	ordered, err := r.order()
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, e := range ordered {
+		r.setState(e, StateStarting, nil)
+		if err := e.handler.Start(); err != nil {
+			r.setState(e, StateFailed, err)
+			slog.Error("starting handler failed", "handler", e.handler.GetIdentity(), "error", err)
+			err = fmt.Errorf("error starting handler %s: %w", e.handler.GetIdentity(), err)
+			r.Stop(context.Background())
+			return err
+		}
+		r.setState(e, StateRunning, nil)
+		r.mu.Lock()
+		r.started = append(r.started, e)
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop stops the started handlers in the reverse order they were started,
+// it returns the first error after trying to stop all of them
+func (r *Registry) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	r.started = nil
+	r.mu.Unlock()
+
+	var firstErr error
+	for i := len(started) - 1; i >= 0; i-- {
+		e := started[i]
+		r.setState(e, StateStopping, nil)
+		if err := e.handler.Stop(ctx); err != nil {
+			slog.Error("stopping handler failed", "handler", e.handler.GetIdentity(), "error", err)
+			r.setState(e, StateFailed, err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error stopping handler %s: %w", e.handler.GetIdentity(), err)
+			}
+			continue
+		}
+		r.setState(e, StateStopped, nil)
+	}
+	return firstErr
+}
+
+// Status returns the state of every added handler in the order they were
+// added, running handlers are asked for their health
+func (r *Registry) Status() []Status {
+	r.mu.Lock()
+	entries := make([]entry, len(r.entries))
+	for i, e := range r.entries {
+		entries[i] = *e
+	}
+	r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(entries))
+	for _, e := range entries {
+		status := Status{
+			Identity:  e.handler.GetIdentity(),
+			State:     e.state,
+			DependsOn: e.dependsOn,
+			Since:     e.since,
+		}
+		err := e.err
+		if e.state == StateRunning {
+			err = e.handler.Health()
+			status.Healthy = err == nil
+		}
 		if err != nil {
-			log.Println("[HANDLER REGISTRAR] error starting handler %s: %+v\n", h.GetIdentity(), err)
+			status.Error = err.Error()
 		}
+		statuses = append(statuses, status)
 	}
+	return statuses
+}
+
+// registry holds the handlers added with the package functions
+var registry = NewRegistry()
+
+// Add adds new handler to handlers, to be started after the handlers named in dependsOn
+func Add(handler Handler, dependsOn ...string) {
+	registry.Add(handler, dependsOn...)
+}
+
+// Start starts all added handlers
+// It returns an error and stops the started ones if any handler fails to start
+func Start() error {
+	return registry.Start()
+}
+
+// Stop stops all started handlers in the reverse order they were started
+func Stop(ctx context.Context) error {
+	return registry.Stop(ctx)
+}
+
+// GetStatus returns the state and health of all added handlers
+func GetStatus() []Status {
+	return registry.Status()
 }
@@ -0,0 +1,133 @@
+package handlerutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type fakeHandler struct {
+	identity string
+	startErr error
+	health   error
+	events   *[]string
+}
+
+func (h *fakeHandler) GetIdentity() string {
+	return h.identity
+}
+
+func (h *fakeHandler) Start() error {
+	*h.events = append(*h.events, "start "+h.identity)
+	return h.startErr
+}
+
+func (h *fakeHandler) Stop(ctx context.Context) error {
+	*h.events = append(*h.events, "stop "+h.identity)
+	return nil
+}
+
+func (h *fakeHandler) Health() error {
+	return h.health
+}
+
+func TestRegistry_StartStop(t *testing.T) {
+	var events []string
+	r := NewRegistry()
+	r.Add(&fakeHandler{identity: "api", events: &events}, "store", "worker")
+	r.Add(&fakeHandler{identity: "worker", events: &events}, "store")
+	r.Add(&fakeHandler{identity: "store", events: &events})
+
+	if err := r.Start(); err != nil {
+		t.Fatalf("Start() err = %v\n", err)
+	}
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() err = %v\n", err)
+	}
+	want := "start store, start worker, start api, stop api, stop worker, stop store"
+	if got := strings.Join(events, ", "); got != want {
+		t.Errorf("events = %v, want %v\n", got, want)
+	}
+}
+
+func TestRegistry_StartFailure(t *testing.T) {
+	var events []string
+	startErr := errors.New("port in use")
+	r := NewRegistry()
+	r.Add(&fakeHandler{identity: "store", events: &events})
+	r.Add(&fakeHandler{identity: "api", startErr: startErr, events: &events}, "store")
+	r.Add(&fakeHandler{identity: "worker", events: &events}, "api")
+
+	if err := r.Start(); !errors.Is(err, startErr) {
+		t.Errorf("Start() err = %v, want %v\n", err, startErr)
+	}
+	want := "start store, start api, stop store"
+	if got := strings.Join(events, ", "); got != want {
+		t.Errorf("events = %v, want %v\n", got, want)
+	}
+
+	statuses := map[string]State{}
+	for _, status := range r.Status() {
+		statuses[status.Identity] = status.State
+	}
+	if fmt.Sprint(statuses) != fmt.Sprint(map[string]State{"store": StateStopped, "api": StateFailed, "worker": StateAdded}) {
+		t.Errorf("Status() = %v\n", statuses)
+	}
+}
+
+func TestRegistry_InvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name    string
+		add     func(r *Registry, events *[]string)
+		wantErr string
+	}{
+		{"unknown", func(r *Registry, events *[]string) {
+			r.Add(&fakeHandler{identity: "api", events: events}, "store")
+		}, "unknown handler store"},
+		{"cycle", func(r *Registry, events *[]string) {
+			r.Add(&fakeHandler{identity: "a", events: events}, "b")
+			r.Add(&fakeHandler{identity: "b", events: events}, "a")
+		}, "depends on itself"},
+		{"duplicate", func(r *Registry, events *[]string) {
+			r.Add(&fakeHandler{identity: "a", events: events})
+			r.Add(&fakeHandler{identity: "a", events: events})
+		}, "added twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []string
+			r := NewRegistry()
+			tt.add(r, &events)
+			err := r.Start()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Start() err = %v, want %v\n", err, tt.wantErr)
+			}
+			if len(events) != 0 {
+				t.Errorf("events = %v, want none\n", events)
+			}
+		})
+	}
+}
+
+func TestRegistry_Status(t *testing.T) {
+	var events []string
+	r := NewRegistry()
+	r.Add(&fakeHandler{identity: "store", events: &events})
+	r.Add(&fakeHandler{identity: "api", health: errors.New("degraded"), events: &events}, "store")
+	if err := r.Start(); err != nil {
+		t.Fatalf("Start() err = %v\n", err)
+	}
+
+	statuses := r.Status()
+	if len(statuses) != 2 {
+		t.Fatalf("Status() = %v, want 2 statuses\n", statuses)
+	}
+	if s := statuses[0]; s.Identity != "store" || s.State != StateRunning || !s.Healthy || s.Error != "" {
+		t.Errorf("Status()[0] = %+v, want healthy store\n", s)
+	}
+	if s := statuses[1]; s.Identity != "api" || s.State != StateRunning || s.Healthy || s.Error != "degraded" {
+		t.Errorf("Status()[1] = %+v, want unhealthy api\n", s)
+	}
+}